@@ -0,0 +1,191 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analytic
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	. "mathx"
+	. "mathx/float"
+)
+
+// Compute class polynomials of degree at most this.
+const classPolynomialDegreeBound = 1 << 12
+
+// A modular function whose values at the CM points of discriminant D
+// generate the ring class field.
+type ClassInvariant int
+
+const (
+	// Klein's j, giving the Hilbert class polynomial.
+	InvariantJ ClassInvariant = iota
+	// Weber's gamma_2, the cube root of j that is real on the imaginary
+	// axis, for D prime to 3; its coefficients are about the cube root
+	// of those of the Hilbert class polynomial.
+	InvariantGamma2
+)
+
+var ErrInvariantUnavailable = errors.New("mathx: class invariant not available for this discriminant")
+var ErrPrecisionLoss = errors.New("mathx: class polynomial coefficients are not close to integers")
+
+// Compute the Hilbert class polynomial H_D, the minimal polynomial of
+// j((-b + sqrt(D)) / 2a), for a discriminant D < 0.
+func HilbertClassPolynomial(D *big.Int) (*IntPolynomial, error) {
+	return ClassPolynomial(D, InvariantJ)
+}
+
+// Compute the polynomial prod (x - f(tau)) over the CM points
+// tau = (-b + sqrt(D)) / 2a of the reduced forms (a, b, c) of
+// discriminant D < 0, for the invariant f. For gamma_2, b is first
+// moved to its class modulo 2a that is divisible by 3, which makes the
+// values conjugates of gamma_2 at the principal point.
+// Cohen, Alg. 7.6.1; Enge and Morain, Comparing invariants for class
+// fields of imaginary quadratic fields.
+func ClassPolynomial(D *big.Int, invariant ClassInvariant) (*IntPolynomial, error) {
+	if D.Sign() >= 0 || new(big.Int).Mod(D, big.NewInt(4)).Int64() > 1 {
+		return nil, ErrNotDiscriminant
+	}
+	if invariant == InvariantGamma2 && new(big.Int).Mod(D, big.NewInt(3)).Sign() == 0 {
+		return nil, ErrInvariantUnavailable
+	}
+	forms, err := ReducedForms(D)
+	if err != nil {
+		return nil, err
+	}
+	d := D.Int64()
+	if len(forms) > classPolynomialDegreeBound {
+		return nil, ErrClassGroupTooLarge
+	}
+
+	// |j(tau)| is about e^(pi sqrt|D| / a), and the coefficients are at
+	// most the product of the 1 + |j(tau)|.
+	bits := float64(len(forms))
+	for _, f := range forms {
+		bits += math.Pi * math.Sqrt(float64(-d)) / float64(f.A().Int64()) / math.Ln2
+	}
+	if invariant == InvariantGamma2 {
+		bits /= 3
+	}
+	precision := uint64(bits) + 64
+
+	coeffs := []*Float{NewFloatInt(NewInt(1), precision)}
+	for _, f := range forms {
+		a, b, c := f.A().Int64(), f.B().Int64(), f.C().Int64()
+		if b < 0 {
+			continue
+		}
+		v := classInvariant(d, a, b, invariant, precision)
+		if b == 0 || a == b || a == c {
+			// (a, -b, c) is not reduced, and the value is real.
+			coeffs = mulMonic(coeffs, v.Real().Neg())
+		} else {
+			// The value at (a, -b, c) is the complex conjugate.
+			re := v.Real()
+			coeffs = mulMonic(coeffs, re.Add(re).Neg(), v.Mul(v.Conj()).Real())
+		}
+	}
+
+	ints := make([]*big.Int, len(coeffs))
+	quarter := big.NewRat(1, 4)
+	for i, x := range coeffs {
+		r := x.Rat()
+		n := new(big.Int).Lsh(r.Num(), 1)
+		n.Add(n, r.Denom())
+		ints[i] = n.Div(n, new(big.Int).Lsh(r.Denom(), 1))
+		if r.Sub(r, new(big.Rat).SetInt(ints[i])).Abs(r).Cmp(quarter) > 0 {
+			return nil, ErrPrecisionLoss
+		}
+	}
+	return NewIntPolynomial(ints), nil
+}
+
+// Multiply the monic polynomial with the given coefficients, constant
+// first, by the monic polynomial x^k + c[0] x^(k-1) + ... + c[k-1].
+func mulMonic(p []*Float, c ...*Float) []*Float {
+	k := len(c)
+	q := make([]*Float, len(p)+k)
+	for i := range q {
+		if i >= k {
+			q[i] = p[i-k].Copy()
+		} else {
+			q[i] = p[0].Sub(p[0])
+		}
+		for j := 1; j <= k; j++ {
+			if i-k+j >= 0 && i-k+j < len(p) {
+				q[i] = q[i].Add(c[j-1].Mul(p[i-k+j]))
+			}
+		}
+	}
+	return q
+}
+
+// Evaluate the invariant at tau = (-b + sqrt(D)) / 2a from
+// t = Delta(2 tau) / Delta(tau) = q prod (1 + q^n)^24, q = e^(2 pi i tau),
+// by j = (1 + 256 t)^3 / t and gamma_2 = (1 + 256 t) / t^(1/3), where
+// t^(1/3) = q^(1/3) prod (1 + q^n)^8.
+func classInvariant(D, a, b int64, invariant ClassInvariant, precision uint64) *Complex {
+	if invariant == InvariantGamma2 {
+		a, b = gamma2Form(a, b, (b*b-D)/(4*a))
+	}
+	pi := Pi(precision)
+	fa := NewFloatInt(NewInt(a), precision)
+	// 2 pi i tau = -r + i theta with r = pi sqrt|D| / a and
+	// theta = -pi b / a.
+	r := NewFloatInt(NewInt(-D), precision).Sqrt().SetPrecision(precision).Mul(pi).Div(fa)
+	theta := pi.Mul(NewFloatInt(NewInt(-b), precision)).Div(fa)
+	// For b = 0, theta is a zero of the default precision.
+	z := NewComplex(r.Neg(), theta.SetPrecision(precision))
+	q := z.Exp()
+
+	// Stop once |q|^n is below 2^-(2 precision).
+	n := int(float64(2*precision)*math.Ln2/r.Float64()) + 1
+	one := NewComplexFloat(NewFloatInt(NewInt(1), precision))
+	P := q.Add(one)
+	qn := q
+	for i := 2; i <= n; i++ {
+		qn = qn.Mul(q)
+		P = P.Mul(qn.Add(one))
+	}
+	P8 := P.Mul(P)
+	P8 = P8.Mul(P8)
+	P8 = P8.Mul(P8)
+	t := q.Mul(P8).Mul(P8).Mul(P8)
+	u := t.Mul(NewComplexFloat(NewFloatInt(NewInt(256), precision))).Add(one)
+	if invariant == InvariantGamma2 {
+		three := NewComplexFloat(NewFloatInt(NewInt(3), precision))
+		return u.Div(z.Div(three).Exp().Mul(P8))
+	}
+	return u.Mul(u).Mul(u).Div(t)
+}
+
+// Return an equivalent form (a', b', c') with a' prime to 3 and 3 | b',
+// taking a' to be one of a, c, a + b + c and a - b + c, one of which is
+// prime to 3, and then translating b' by a multiple of 2a'.
+func gamma2Form(a, b, c int64) (int64, int64) {
+	switch {
+	case a%3 == 0 && c%3 != 0:
+		a, b = c, -b
+	case a%3 == 0 && (a+b+c)%3 != 0:
+		a, b = a+b+c, b+2*c
+	case a%3 == 0:
+		a, b = a-b+c, b-2*c
+	}
+	for b%3 != 0 {
+		b += 2 * a
+	}
+	return a, b
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analytic
+
+import (
+	"math/big"
+	. "mathx"
+	"testing"
+)
+
+func TestHilbertClassPolynomial(t *testing.T) {
+	testCases := []struct {
+		D    int64
+		poly string
+	}{
+		{-3, "x"},
+		{-4, "x - 1728"},
+		{-7, "x + 3375"},
+		{-8, "x - 8000"},
+		{-12, "x - 54000"},
+		{-16, "x - 287496"},
+		{-27, "x + 12288000"},
+		{-28, "x - 16581375"},
+		{-163, "x + 262537412640768000"},
+		{-15, "x^2 + 191025*x - 121287375"},
+		{-20, "x^2 - 1264000*x - 681472000"},
+		{-23, "x^3 + 3491750*x^2 - 5151296875*x + 12771880859375"},
+	}
+	for _, c := range testCases {
+		H, err := HilbertClassPolynomial(big.NewInt(c.D))
+		if err != nil {
+			t.Fatalf("%d: %v", c.D, err)
+		}
+		if H.String() != c.poly {
+			t.Errorf("%d: expected %s, got %s", c.D, c.poly, H)
+		}
+	}
+	if _, err := HilbertClassPolynomial(big.NewInt(-5)); err != ErrNotDiscriminant {
+		t.Errorf("expected ErrNotDiscriminant, got %v", err)
+	}
+}
+
+func TestClassPolynomialGamma2(t *testing.T) {
+	for _, D := range []int64{-4, -7, -20, -23, -71, -56, -119, -199, -400, -1003} {
+		bD := big.NewInt(D)
+		H, err := HilbertClassPolynomial(bD)
+		if err != nil {
+			t.Fatalf("%d: %v", D, err)
+		}
+		W, err := ClassPolynomial(bD, InvariantGamma2)
+		if err != nil {
+			t.Fatalf("%d: %v", D, err)
+		}
+		h, _ := ClassNumberImagQuad(bD)
+		if int64(H.Degree()) != h.Int64() || W.Degree() != H.Degree() {
+			t.Fatalf("%d: degrees %d and %d, class number %s", D, H.Degree(), W.Degree(), h)
+		}
+		if !W.IsIrreducible() {
+			t.Errorf("%d: %s is reducible", D, W)
+		}
+		// The roots of W are cube roots of those of H.
+		coeffs := make([]*big.Int, 3*H.Degree()+1)
+		for i := range coeffs {
+			coeffs[i] = big.NewInt(0)
+			if i%3 == 0 {
+				coeffs[i] = H.Coeff(i / 3)
+			}
+		}
+		if _, err := NewIntPolynomial(coeffs).ExactDiv(W); err != nil {
+			t.Errorf("%d: %s does not divide H(x^3)", D, W)
+		}
+	}
+	if _, err := ClassPolynomial(big.NewInt(-15), InvariantGamma2); err != ErrInvariantUnavailable {
+		t.Errorf("expected ErrInvariantUnavailable, got %v", err)
+	}
+}
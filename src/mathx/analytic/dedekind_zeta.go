@@ -0,0 +1,340 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package analytic
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	. "mathx"
+	. "mathx/float"
+)
+
+// Sum at most this many terms of the Dirichlet series of zeta_k.
+const zetaTermBound = 1 << 18
+
+var ErrZetaPole = errors.New("mathx: the Dedekind zeta function has a pole at s = 1")
+var ErrZetaArgument = errors.New("mathx: cannot evaluate zeta at this argument")
+
+// Compute the truncated Euler product over the primes p <= bound of
+// prod_{P | p} (1 - N(P)^-s)^-1, which converges to zeta_k(s) for s > 1,
+// to the precision of s.
+func DedekindZetaEulerProduct(k *NumberField, s *Float, bound int64) (*Float, error) {
+	precision := s.Precision()
+	one := NewFloatInt(NewInt(1), precision)
+	if s.Cmp(one) <= 0 {
+		return nil, ErrZetaArgument
+	}
+	z := one
+	for p := int64(2); p <= bound; p++ {
+		if !big.NewInt(p).ProbablyPrime(10) {
+			continue
+		}
+		degrees, err := k.ResidueDegrees(p)
+		if err != nil {
+			return nil, err
+		}
+		logP := NewFloatInt(NewInt(p), precision).Log()
+		for _, f := range degrees {
+			x := s.Mul(logP).Mul(NewFloatInt(NewInt(int64(f)), precision)).Neg().Exp()
+			z = z.Div(one.Sub(x))
+		}
+	}
+	return z, nil
+}
+
+// The completed zeta function Lambda(s) = A^s gamma(s) zeta_k(s), with
+// A = sqrt(|d|) and gamma(s) = Gamma_R(s)^r1 Gamma_C(s)^r2,
+// where Gamma_R(s) = pi^(-s/2) Gamma(s/2) and Gamma_C(s) =
+// 2 (2 pi)^-s Gamma(s), satisfies Lambda(s) = Lambda(1 - s). The inverse
+// Mellin transform phi of gamma is the multiplicative convolution of r1
+// copies of 2 e^(-pi x^2) and r2 of 2 e^(-2 pi x). All but the last
+// factor are tabulated on a grid in log x, where the trapezoidal rule
+// with step h is accurate to about e^(-pi^2 / 2h), and the last one is
+// applied exactly. Terms below 2^-bits, for the 2 precision bits that a
+// Float keeps, are dropped.
+// Cohen, Sec. 10.3; Dokchitser, Computing special values of motivic
+// L-functions, 2004.
+type zetaGamma struct {
+	r1, r2    int
+	precision uint64
+	a, pi     *Float
+	eps       *Float
+	// phi of all factors but the last at exp(v0 + i h), or nil where it
+	// is below eps, and exp(-v0 - i h). grid is nil if there is a single
+	// factor.
+	v0, h float64
+	grid  []*Float
+	scale []*Float
+	// Whether the last factor is Gamma_R, and the x above which its
+	// inverse Mellin transform is below eps.
+	lastReal bool
+	lastMax  float64
+}
+
+func newZetaGamma(k *NumberField, precision uint64) (*zetaGamma, error) {
+	D := k.Discriminant()
+	if D == nil {
+		// The ring of integers could not be found; ResidueDegrees says why.
+		_, err := k.ResidueDegrees(2)
+		return nil, err
+	}
+	r1, r2 := k.Signature()
+	bits := float64(2*precision) + 8
+	g := &zetaGamma{r1: r1, r2: r2, precision: precision, pi: Pi(precision)}
+	g.a = NewFloatInt((*Int)(new(big.Int).Abs(D)), precision).Sqrt().SetPrecision(precision)
+	g.eps = g.float(1).Div(NewFloatInt(NewInt(1).Lsh(uint(bits)), precision))
+	g.h = math.Pi * math.Pi / (2 * bits * math.Ln2)
+	factors := []bool{}
+	for i := 0; i < r1; i++ {
+		factors = append(factors, true)
+	}
+	for i := 0; i < r2; i++ {
+		factors = append(factors, false)
+	}
+	m := len(factors)
+	g.lastReal = factors[m-1]
+	g.lastMax = gammaFactorBound(g.lastReal, bits)
+	if m == 1 {
+		return g, nil
+	}
+	// Arguments x >= 1 / (2A) are needed; every convolution needs pad
+	// more below, with 2 e^(-2 pi e^pad) < 2^-bits, and shifts the mass up
+	// by as much.
+	pad := math.Log(gammaFactorBound(false, bits)) + 1
+	g.v0 = -math.Log(2*g.a.Float64()) - pad*float64(m)
+	vmax := pad * float64(m+1)
+	size := int((vmax-g.v0)/g.h) + 1
+	// The nodes must be equally spaced to the full precision.
+	h := g.float(g.h)
+	g.scale = make([]*Float, size)
+	for i := range g.scale {
+		g.scale[i] = g.float(-g.v0).Sub(h.Mul(g.integer(int64(i)))).Exp()
+	}
+	g.grid = make([]*Float, size)
+	for i := range g.grid {
+		if x := math.Exp(g.v0 + float64(i)*g.h); x < gammaFactorBound(factors[0], bits) {
+			g.grid[i] = g.clip(g.gammaFactorInverse(factors[0], g.float(1).Div(g.scale[i])))
+		}
+	}
+	for _, real := range factors[1 : m-1] {
+		// kernel[size - 1 + d] is the factor at exp(d h).
+		bound := gammaFactorBound(real, bits)
+		kernel := make([]*Float, 2*size-1)
+		for d := 1 - size; d < size; d++ {
+			if math.Exp(float64(d)*g.h) < bound {
+				kernel[size-1+d] = g.gammaFactorInverse(real, h.Mul(g.integer(int64(d))).Exp())
+			}
+		}
+		next := make([]*Float, size)
+		for i := range next {
+			sum := g.float(0)
+			for j, y := range g.grid {
+				if y != nil && kernel[size-1+i-j] != nil {
+					sum = sum.Add(y.Mul(kernel[size-1+i-j]))
+				}
+			}
+			next[i] = g.clip(sum.Mul(h))
+		}
+		g.grid = next
+	}
+	return g, nil
+}
+
+func (g *zetaGamma) float(x float64) *Float {
+	return NewFloat(x).SetPrecision(g.precision)
+}
+
+func (g *zetaGamma) integer(n int64) *Float {
+	return NewFloatInt(NewInt(n), g.precision)
+}
+
+// Return x, or nil if |x| < eps.
+func (g *zetaGamma) clip(x *Float) *Float {
+	if x.Abs().Cmp(g.eps) < 0 {
+		return nil
+	}
+	return x
+}
+
+// Return the x above which 2 e^(-pi x^2), or 2 e^(-2 pi x), is below
+// 2^-bits.
+func gammaFactorBound(real bool, bits float64) float64 {
+	if real {
+		return math.Sqrt((bits + 1) * math.Ln2 / math.Pi)
+	}
+	return (bits + 1) * math.Ln2 / (2 * math.Pi)
+}
+
+// Compute 2 e^(-pi x^2), the inverse Mellin transform of Gamma_R(s), or
+// 2 e^(-2 pi x), that of Gamma_C(s).
+func (g *zetaGamma) gammaFactorInverse(real bool, x *Float) *Float {
+	y := g.pi.Mul(x)
+	if real {
+		y = y.Mul(x)
+	} else {
+		y = y.Add(y)
+	}
+	e := y.Neg().Exp()
+	return e.Add(e)
+}
+
+// Compute int_1^oo psi(x t) t^(s - 1) dt for psi = gammaFactorInverse:
+// Gamma(s/2, pi x^2) / (pi x^2)^(s/2), or 2 Gamma(s, 2 pi x) / (2 pi x)^s.
+func (g *zetaGamma) gammaFactorIncomplete(real bool, s, x *Float) *Float {
+	y := g.pi.Mul(x)
+	if real {
+		y = y.Mul(x)
+		a := s.Div(g.float(2))
+		return UpperGamma(a, y).Div(a.Mul(y.Log()).Exp())
+	}
+	y = y.Add(y)
+	G := UpperGamma(s, y).Div(s.Mul(y.Log()).Exp())
+	return G.Add(G)
+}
+
+// Evaluate phi(x), or G_s(x) = int_1^oo phi(x t) t^(s - 1) dt if s is not
+// nil, summing the last factor over the grid.
+func (g *zetaGamma) eval(s, x *Float) *Float {
+	last := func(y *Float) *Float {
+		if s == nil {
+			return g.gammaFactorInverse(g.lastReal, y)
+		}
+		return g.gammaFactorIncomplete(g.lastReal, s, y)
+	}
+	if g.grid == nil {
+		return last(x)
+	}
+	xf := x.Float64()
+	sum := g.float(0)
+	for j, y := range g.grid {
+		if y != nil && xf*math.Exp(-g.v0-float64(j)*g.h) < g.lastMax {
+			sum = sum.Add(y.Mul(last(x.Mul(g.scale[j]))))
+		}
+	}
+	return sum.Mul(g.float(g.h))
+}
+
+// Evaluate gamma(s).
+func (g *zetaGamma) gamma(s *Float) *Float {
+	half := s.Div(g.float(2))
+	gr := half.Mul(g.pi.Log()).Neg().Exp().Mul(half.Gamma())
+	twoPi := g.pi.Add(g.pi)
+	gc := s.Mul(twoPi.Log()).Neg().Exp().Mul(s.Gamma())
+	gc = gc.Add(gc)
+	z := g.float(1)
+	for i := 0; i < g.r1; i++ {
+		z = z.Mul(gr)
+	}
+	for i := 0; i < g.r2; i++ {
+		z = z.Mul(gc)
+	}
+	return z
+}
+
+// Find x with phi(y) < eps for all y >= x.
+func (g *zetaGamma) cutoff() float64 {
+	x := 1.0
+	for g.eval(nil, g.float(x)).Cmp(g.eps) >= 0 {
+		x *= 1.25
+	}
+	return x
+}
+
+// Evaluate theta(t) = sum a_n phi(n t / A).
+func (g *zetaGamma) theta(a []int64, t *Float) *Float {
+	sum := g.float(0)
+	u := t.Div(g.a)
+	for n := 1; n < len(a); n++ {
+		if a[n] != 0 {
+			c := g.integer(a[n])
+			sum = sum.Add(c.Mul(g.eval(nil, u.Mul(g.integer(int64(n))))))
+		}
+	}
+	return sum
+}
+
+// Return the ideal counts up to the last n with phi(n t / A) at least
+// eps for t >= 4/5.
+func (g *zetaGamma) terms(k *NumberField) ([]int64, error) {
+	N := g.cutoff()*g.a.Float64()/0.8 + 1
+	if N > zetaTermBound {
+		return nil, ErrZetaArgument
+	}
+	return k.IdealCounts(int(N))
+}
+
+// The residue rho of Lambda at s = 1, from the theta relation
+// theta(1/t) = t theta(t) + rho (t - 1), at t = 5/4.
+func (g *zetaGamma) residue(a []int64) *Float {
+	t := g.float(1.25)
+	r := g.theta(a, g.float(1).Div(t)).Sub(t.Mul(g.theta(a, t)))
+	return r.Mul(g.float(4))
+}
+
+// Compute the residue of zeta_k(s) at s = 1 to the given precision, which
+// the analytic class number formula equals 2^r1 (2 pi)^r2 h R /
+// (w sqrt(|d|)), from the functional equation: it is
+// rho / (A gamma(1)) = rho pi^r2 / A for the residue rho of Lambda, found
+// from the theta series of the ideal counts.
+func DedekindZetaResidue(k *NumberField, precision uint64) (*Float, error) {
+	g, err := newZetaGamma(k, precision)
+	if err != nil {
+		return nil, err
+	}
+	a, err := g.terms(k)
+	if err != nil {
+		return nil, err
+	}
+	z := g.residue(a)
+	for i := 0; i < g.r2; i++ {
+		z = z.Mul(g.pi)
+	}
+	return z.Div(g.a), nil
+}
+
+// Compute zeta_k(s) to the precision of s, for real s other than 1 and
+// the non-positive integers, from
+// Lambda(s) = sum a_n (G_s(n / A) + G_{1-s}(n / A)) + rho / (s - 1) - rho / s,
+// which follows from splitting the Mellin integral of theta at t = 1.
+func DedekindZeta(k *NumberField, s *Float) (*Float, error) {
+	r := s.Rat()
+	if r.Cmp(big.NewRat(1, 1)) == 0 {
+		return nil, ErrZetaPole
+	}
+	if r.IsInt() && r.Sign() <= 0 {
+		return nil, ErrZetaArgument
+	}
+	g, err := newZetaGamma(k, s.Precision())
+	if err != nil {
+		return nil, err
+	}
+	a, err := g.terms(k)
+	if err != nil {
+		return nil, err
+	}
+	one := g.float(1)
+	rho := g.residue(a)
+	lambda := rho.Div(s.Sub(one)).Sub(rho.Div(s))
+	u := one.Div(g.a)
+	for n := 1; n < len(a); n++ {
+		if a[n] != 0 {
+			x := u.Mul(g.integer(int64(n)))
+			c := g.integer(a[n])
+			lambda = lambda.Add(c.Mul(g.eval(s, x).Add(g.eval(one.Sub(s), x))))
+		}
+	}
+	return lambda.Div(s.Mul(g.a.Log()).Exp().Mul(g.gamma(s))), nil
+}
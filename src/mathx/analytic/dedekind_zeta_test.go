@@ -0,0 +1,87 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package analytic
+
+import (
+	"math"
+	. "mathx"
+	. "mathx/float"
+	"testing"
+)
+
+func TestDedekindZeta(t *testing.T) {
+	phi := (1 + math.Sqrt(5)) / 2
+	// The roots 2 cos(2 pi j / 7) of x^3 + x^2 - 2x - 1 and the units
+	// theta and theta + 1.
+	c1, c2 := 2*math.Cos(2*math.Pi/7), 2*math.Cos(4*math.Pi/7)
+	R7 := math.Abs(math.Log(math.Abs(c1))*math.Log(math.Abs(c2+1)) - math.Log(math.Abs(c2))*math.Log(math.Abs(c1+1)))
+	testCases := []struct {
+		polyString string
+		residue    float64
+		zeta2      float64
+	}{
+		// pi / 4 and zeta(2) times Catalan's constant.
+		{"x^2 + 1", math.Pi / 4, 1.5067030099229850},
+		// zeta(2) L(2, chi_5) = 2 pi^4 / (75 sqrt(5)).
+		{"x^2 - x - 1", 4 * math.Log(phi) / (2 * math.Sqrt(5)), 2 * math.Pow(math.Pi, 4) / (75 * math.Sqrt(5))},
+		// 2 (2 pi) R / (2 sqrt(23)) with the unit the real root 1.3247...
+		{"x^3 - x - 1", 4 * math.Pi * math.Log(1.324717957244746) / (2 * math.Sqrt(23)), 0},
+		{"x^3 + x^2 - 2*x - 1", 8 * R7 / (2 * 7), 0},
+	}
+	two := NewFloatInt(NewInt(2), 32)
+	for _, c := range testCases {
+		k := MakeNumberField(ParseIntPoly(c.polyString))
+		res, err := DedekindZetaResidue(k, 32)
+		if err != nil {
+			t.Fatalf("%s: %v", c.polyString, err)
+		}
+		if r := res.Float64(); math.Abs(r-c.residue) > 1e-14 {
+			t.Errorf("%s: residue %v, expected %v", c.polyString, r, c.residue)
+		}
+		z, err := DedekindZeta(k, two)
+		if err != nil {
+			t.Fatalf("%s: %v", c.polyString, err)
+		}
+		if c.zeta2 != 0 && math.Abs(z.Float64()-c.zeta2) > 1e-14 {
+			t.Errorf("%s: zeta(2) = %v, expected %v", c.polyString, z.Float64(), c.zeta2)
+		}
+		e, err := DedekindZetaEulerProduct(k, two, 2000)
+		if err != nil {
+			t.Fatalf("%s: %v", c.polyString, err)
+		}
+		if math.Abs(z.Float64()-e.Float64()) > 1e-3 {
+			t.Errorf("%s: zeta(2) = %v, Euler product %v", c.polyString, z.Float64(), e.Float64())
+		}
+	}
+
+	// The residue pi / 4 for Q(i) to 40 digits, and zeta(1/2) L(1/2, chi_-4).
+	k := MakeNumberField(ParseIntPoly("x^2 + 1"))
+	res, err := DedekindZetaResidue(k, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !agreesWith(res, "7853981633974483096156608458198757210492", 0) {
+		t.Errorf("residue %v, expected pi / 4", res.Float64())
+	}
+	if z, _ := DedekindZeta(k, NewFloat(0.5).SetPrecision(32)); math.Abs(z.Float64()+0.9750662300) > 1e-9 {
+		t.Errorf("zeta(1/2) = %v, expected -0.9750662300", z.Float64())
+	}
+	if _, err := DedekindZeta(k, NewFloatInt(NewInt(1), 32)); err != ErrZetaPole {
+		t.Errorf("expected ErrZetaPole, got %v", err)
+	}
+	if _, err := DedekindZeta(k, NewFloatInt(NewInt(-2), 32)); err != ErrZetaArgument {
+		t.Errorf("expected ErrZetaArgument, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analytic
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	. "mathx"
+	. "mathx/float"
+)
+
+// Sum at most this many terms of an L-series.
+const lSeriesTermBound = 1 << 20
+
+var ErrTrivialCharacter = errors.New("mathx: L(s, chi) has a pole at s = 1")
+var ErrConductorTooLarge = errors.New("mathx: conductor too large for the L-series")
+
+// Compute L(1, chi) for the quadratic character chi of a fundamental
+// discriminant D != 1, of conductor f = |D|, by the series that the
+// functional equation gives, whose terms decay like e^(-pi n^2 / f):
+// for D < 0,
+// L(1, chi) = pi / sqrt(f) sum chi(n) (erfc(n sqrt(pi / f))
+// + sqrt(f) / (pi n) e^(-pi n^2 / f)),
+// and for D > 0,
+// L(1, chi) = 1 / sqrt(f) sum chi(n) (sqrt(f) / n erfc(n sqrt(pi / f))
+// + E1(pi n^2 / f)).
+// Cohen, Prop. 5.3.14 and 5.6.11.
+func L1(chi *QuadraticCharacter, precision uint64) (*Float, error) {
+	D := chi.Discriminant()
+	if D.Cmp(big.NewInt(1)) == 0 {
+		return nil, ErrTrivialCharacter
+	}
+	f := chi.Conductor()
+	if !f.IsInt64() {
+		return nil, ErrConductorTooLarge
+	}
+	// Stop once pi n^2 / f exceeds the 2 * precision bits kept.
+	ff := float64(f.Int64())
+	N := int64(math.Sqrt(float64(2*precision)*math.Ln2*ff/math.Pi)) + 2
+	if N > lSeriesTermBound {
+		return nil, ErrConductorTooLarge
+	}
+
+	pi := Pi(precision)
+	fl := NewFloatInt((*Int)(f), precision)
+	sqrtF := fl.Sqrt().SetPrecision(precision)
+	s := pi.Div(fl).Sqrt().SetPrecision(precision)
+	sum := NewFloatInt(NewInt(0), precision)
+	values := chi.Values(int(N))
+	for n := int64(1); n < N; n++ {
+		if values[n] == 0 {
+			continue
+		}
+		bn := NewFloatInt(NewInt(n), precision)
+		x := bn.Mul(s)
+		var term *Float
+		if D.Sign() < 0 {
+			e := x.Mul(x).Neg().Exp()
+			term = x.Erfc().Add(sqrtF.Mul(e).Div(pi.Mul(bn)))
+		} else {
+			term = sqrtF.Mul(x.Erfc()).Div(bn).Add(x.Mul(x).ExpIntegralE1())
+		}
+		if values[n] < 0 {
+			term = term.Neg()
+		}
+		sum = sum.Add(term)
+	}
+	if D.Sign() < 0 {
+		return sum.Mul(pi).Div(sqrtF), nil
+	}
+	return sum.Div(sqrtF), nil
+}
+
+// Evaluate the analytic class number formula for the quadratic field of
+// fundamental discriminant D: h = w sqrt(|D|) L(1, chi_D) / (2 pi) for
+// D < 0, with w the number of roots of unity, and
+// h R = sqrt(D) L(1, chi_D) / 2 for D > 0, with R the regulator.
+func ClassNumberFormula(D *big.Int, precision uint64) (*Float, error) {
+	chi, err := NewQuadraticCharacter(D)
+	if err != nil {
+		return nil, err
+	}
+	L, err := L1(chi, precision)
+	if err != nil {
+		return nil, err
+	}
+	sqrtD := NewFloatInt((*Int)(new(big.Int).Abs(D)), precision).Sqrt().SetPrecision(precision)
+	two := NewFloatInt(NewInt(2), precision)
+	if D.Sign() > 0 {
+		return sqrtD.Mul(L).Div(two), nil
+	}
+	w := int64(2)
+	switch D.Int64() {
+	case -3:
+		w = 6
+	case -4:
+		w = 4
+	}
+	h := sqrtD.Mul(L).Mul(NewFloatInt(NewInt(w), precision))
+	return h.Div(two.Mul(Pi(precision))), nil
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analytic
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	. "mathx"
+	"testing"
+)
+
+func TestL1(t *testing.T) {
+	testCases := []struct {
+		D      int64
+		digits string
+	}{
+		// pi / 4, pi / (3 sqrt(3)), 2 log((1 + sqrt(5)) / 2) / sqrt(5).
+		{-4, "785398163397448309615660845819875721049292349843776"},
+		{-3, "604599788078072616864692752547385244094688749364246"},
+		{5, "430408940964004038889433232950605425424570682540289"},
+	}
+	for _, c := range testCases {
+		chi, _ := NewQuadraticCharacter(big.NewInt(c.D))
+		L, err := L1(chi, 192)
+		if err != nil {
+			t.Fatalf("%d: %v", c.D, err)
+		}
+		if !agreesWith(L, c.digits, 0) {
+			t.Errorf("%d: L(1, chi) = %v, expected 0.%s", c.D, L.Float64(), c.digits)
+		}
+	}
+	chi, _ := NewQuadraticCharacter(big.NewInt(1))
+	if _, err := L1(chi, 64); err != ErrTrivialCharacter {
+		t.Errorf("expected ErrTrivialCharacter, got %v", err)
+	}
+}
+
+func TestClassNumberFormula(t *testing.T) {
+	for _, D := range []int64{-3, -4, -7, -23, -84, -3299, -11015, 5, 8, 12, 40, 79 * 4, 229, 1003 * 4} {
+		bD := big.NewInt(D)
+		hR, err := ClassNumberFormula(bD, 64)
+		if err != nil {
+			t.Fatalf("%d: %v", D, err)
+		}
+		if D < 0 {
+			h, _ := ClassNumberImagQuad(bD)
+			if math.Abs(hR.Float64()-float64(h.Int64())) > 1e-12 {
+				t.Errorf("%d: formula gives %v, class number %s", D, hR.Float64(), h)
+			}
+			continue
+		}
+		poly := fmt.Sprintf("x^2 - %d", D/4)
+		if D%4 == 1 {
+			poly = fmt.Sprintf("x^2 - x - %d", (D-1)/4)
+		}
+		k := MakeNumberField(ParseIntPoly(poly))
+		R, err := Regulator(k, 64)
+		if err != nil {
+			t.Fatalf("%s: %v", poly, err)
+		}
+		h := k.ClassNumber()
+		if expect := float64(h) * R.Float64(); math.Abs(hR.Float64()-expect) > 1e-12*expect {
+			t.Errorf("%d: formula gives %v, h R = %v", D, hR.Float64(), expect)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package analytic computes analytic invariants of number fields, such
+// as regulators, to arbitrary precision.
+package analytic
+
+import (
+	. "mathx"
+	. "mathx/float"
+)
+
+// Compute the regulator log(e) of a real quadratic field to the given
+// precision, from its fundamental unit e = (u + v sqrt(D)) / 2.
+func Regulator(k *NumberField, precision uint64) (*Float, error) {
+	u, v, err := k.FundamentalUnit()
+	if err != nil {
+		return nil, err
+	}
+	D := k.Discriminant()
+	e := NewFloatInt((*Int)(D), precision).Sqrt().SetPrecision(precision)
+	e = e.Mul(NewFloatInt((*Int)(v), precision)).Add(NewFloatInt((*Int)(u), precision))
+	return e.Div(NewFloatInt(NewInt(2), precision)).Log(), nil
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analytic
+
+import (
+	"math/big"
+	. "mathx"
+	. "mathx/float"
+	"testing"
+)
+
+// Tell if x agrees with the decimal expansion digits (with the decimal
+// point after the first point digits) to within 10^-(len(digits) - point - 1).
+func agreesWith(x *Float, digits string, point int) bool {
+	n, _ := new(big.Int).SetString(digits, 10)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(len(digits)-point)), nil)
+	y := x.Mul(NewFloatInt((*Int)(scale), x.Precision())).Sub(NewFloatInt((*Int)(n), x.Precision()))
+	return y.Abs().Cmp(NewFloat(10)) < 0
+}
+
+func TestRegulator(t *testing.T) {
+	testCases := []struct {
+		polyString string
+		digits     string
+		point      int
+	}{
+		{"x^2 - 2", "88137358701954302523260932497979230902816032826163541075329560865", 0},
+		{"x^2 - 94", "15271002103031182876932522997517649573530734783032238114262368877", 2},
+	}
+	for _, testCase := range testCases {
+		k := MakeNumberField(ParseIntPoly(testCase.polyString))
+		R, err := Regulator(k, 256)
+		if err != nil {
+			t.Errorf("%s: %v", testCase.polyString, err)
+			continue
+		}
+		if !agreesWith(R, testCase.digits, testCase.point) {
+			t.Errorf("%s: regulator %v, expected %s", testCase.polyString, R.Float64(), testCase.digits)
+		}
+	}
+	if _, err := Regulator(MakeNumberField(ParseIntPoly("x^2 + 1")), 64); err != ErrNotRealQuadratic {
+		t.Errorf("expected ErrNotRealQuadratic, got %v", err)
+	}
+}
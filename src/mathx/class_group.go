@@ -0,0 +1,207 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Enumerate class groups of at most this many elements.
+const classGroupBound = 1 << 20
+
+var ErrNotImaginaryQuadratic = errors.New("mathx: field is not imaginary quadratic")
+var ErrClassGroupTooLarge = errors.New("mathx: class group too large to enumerate")
+
+// The class group of a number field or of a quadratic order, as a
+// product of cyclic groups C_d1 x C_d2 x ... with d1 | d2 | ..., each
+// with a generator.
+type ClassGroup struct {
+	invariants []int
+	forms      []*QuadraticForm
+	generators []*Ideal
+}
+
+// Return the orders d1 | d2 | ... of the cyclic factors, all larger
+// than 1; the trivial group has none.
+func (g *ClassGroup) Invariants() []int {
+	return append([]int{}, g.invariants...)
+}
+
+// Return ideals whose classes generate the cyclic factors, or nil for
+// the class group of a non-maximal order.
+func (g *ClassGroup) Generators() []*Ideal {
+	if g.generators == nil {
+		return nil
+	}
+	return append([]*Ideal{}, g.generators...)
+}
+
+// Return reduced forms whose classes generate the cyclic factors.
+func (g *ClassGroup) Forms() []*QuadraticForm {
+	return append([]*QuadraticForm{}, g.forms...)
+}
+
+func (g *ClassGroup) Order() int {
+	h := 1
+	for _, d := range g.invariants {
+		h *= d
+	}
+	return h
+}
+
+func (g *ClassGroup) String() string {
+	if len(g.invariants) == 0 {
+		return "C1"
+	}
+	s := []string{}
+	for _, d := range g.invariants {
+		s = append(s, "C"+strconv.Itoa(d))
+	}
+	return strings.Join(s, " x ")
+}
+
+// Compute the class group of an imaginary quadratic field, mapping the
+// generating forms of ClassGroupImagQuad to ideals.
+func (k *NumberField) ClassGroup() (*ClassGroup, error) {
+	if k.Degree() != 2 || k.polynomial.Discriminant().Sign() > 0 {
+		return nil, ErrNotImaginaryQuadratic
+	}
+	D := k.Discriminant()
+	if D == nil {
+		return nil, ErrNotSquareFree
+	}
+	group, err := ClassGroupImagQuad(D)
+	if err != nil {
+		return nil, err
+	}
+	group.generators = []*Ideal{}
+	for _, f := range group.forms {
+		I, err := f.Ideal(k)
+		if err != nil {
+			return nil, err
+		}
+		group.generators = append(group.generators, I)
+	}
+	return group, nil
+}
+
+// Compute the class group of primitive positive definite forms of
+// discriminant D < 0, the ring class group of the order of discriminant
+// D. Prime forms are added as generators until they generate all h
+// classes; primes dividing the conductor give imprimitive forms and are
+// skipped. The relations among the generators come from the Cayley graph
+// of the group, and the Smith normal form of the relation matrix gives
+// the invariants.
+// Cohen, Sec. 5.4 and Alg. 2.4.14.
+func ClassGroupImagQuad(D *big.Int) (*ClassGroup, error) {
+	hBig, err := ClassNumberImagQuad(D)
+	if err != nil {
+		return nil, err
+	}
+	if hBig.Cmp(big.NewInt(classGroupBound)) > 0 {
+		return nil, ErrClassGroupTooLarge
+	}
+	h := int(hBig.Int64())
+	group := &ClassGroup{}
+	if h == 1 {
+		return group, nil
+	}
+
+	gens := []*QuadraticForm{}
+	elements := map[string]bool{}
+	subgroup := []*QuadraticForm{PrincipalForm(D)}
+	elements[subgroup[0].key()] = true
+	for p := int64(2); len(subgroup) < h; p++ {
+		if !big.NewInt(p).ProbablyPrime(10) {
+			continue
+		}
+		g := PrimeForm(D, big.NewInt(p))
+		if g == nil || !g.IsPrimitive() || elements[g.key()] {
+			continue
+		}
+		gens = append(gens, g)
+		// Add the cosets H g, H g^2, ... until g^k lies in H.
+		size := len(subgroup)
+		x := g
+		for !elements[x.key()] {
+			for _, y := range subgroup[:size] {
+				z := y.Compose(x)
+				elements[z.key()] = true
+				subgroup = append(subgroup, z)
+			}
+			x = x.Compose(g)
+		}
+	}
+
+	// Give every class an exponent vector along a spanning tree of the
+	// Cayley graph; the other edges are relations.
+	n := len(gens)
+	index := map[string]int{subgroup[0].key(): 0}
+	vectors := [][]big.Int{make([]big.Int, n)}
+	queue := []*QuadraticForm{subgroup[0]}
+	relations := [][]big.Int{}
+	for i := 0; i < len(queue); i++ {
+		for j, g := range gens {
+			y := queue[i].Compose(g)
+			v := make([]big.Int, n)
+			for l := range v {
+				v[l].Set(&vectors[i][l])
+			}
+			v[j].Add(&v[j], intOne)
+			if m, ok := index[y.key()]; ok {
+				for l := range v {
+					v[l].Sub(&v[l], &vectors[m][l])
+				}
+				if !isZeroVector(v) {
+					relations = append(relations, v)
+				}
+				continue
+			}
+			index[y.key()] = len(queue)
+			queue = append(queue, y)
+			vectors = append(vectors, v)
+		}
+	}
+
+	bh := big.NewInt(int64(h))
+	diag, vinv := smithForm(hnfLower(relations, n, bh))
+	for i := range diag {
+		if diag[i].Cmp(intOne) == 0 {
+			continue
+		}
+		x := PrincipalForm(D)
+		for j, g := range gens {
+			e := new(big.Int).Mod(&vinv[i][j], bh)
+			x = x.Compose(g.Pow(e))
+		}
+		group.invariants = append(group.invariants, int(diag[i].Int64()))
+		group.forms = append(group.forms, x)
+	}
+	return group, nil
+}
+
+// Return sqrt(D) for the discriminant D of a quadratic field, from
+// (2 c2 a + c1)^2 = disc(f) for the defining polynomial
+// f = c2 x^2 + c1 x + c0.
+func (k *NumberField) sqrtDiscriminant(D *big.Int) *NumberFieldElement {
+	f := k.polynomial
+	m := Sqrt(new(big.Int).Quo(f.Discriminant(), D))
+	x := k.NewElement([]*big.Rat{new(big.Rat).SetInt(&f.coeffs[1]), new(big.Rat).SetInt(new(big.Int).Lsh(&f.coeffs[2], 1))})
+	return x.MulRat(new(big.Rat).SetFrac(intOne, m))
+}
@@ -0,0 +1,332 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"errors"
+	"math"
+	"math/big"
+)
+
+const (
+	// Truncate the Euler product for L(1, chi_D) at this bound.
+	eulerProductBound = 1 << 18
+	// Above this |D|, ClassNumber uses baby-step giant-step instead of
+	// enumerating reduced forms.
+	classNumberBSGSBound = 1 << 24
+	// How many prime forms to try before giving up on pinning h down.
+	classNumberBSGSElements = 30
+)
+
+var ErrNotDiscriminant = errors.New("mathx: not a discriminant of the expected kind")
+var ErrClassNumberAmbiguous = errors.New("mathx: class number could not be determined")
+
+var eulerProductPrimes []int64
+
+// Compute the class number h(D) of primitive positive definite forms of
+// discriminant D < 0, that is, of the order of discriminant D. The
+// Euler product of L(1, chi_D) up to eulerProductBound gives an interval
+// around h = w sqrt(|D|) L(1, chi_D) / (2 pi); the orders of prime forms,
+// found by baby-step giant-step, and the 2-rank from genus theory are
+// combined until a single multiple of their lcm lies in the interval.
+// Cohen, Alg. 5.4.10.
+//
+// For |D| >= 2^24 the result is conditional: the interval is heuristic,
+// and h is only certified to be killed by every prime form of norm up to
+// Bach's bound 6 log^2 |D|, which generate the class group under GRH.
+// ErrClassNumberAmbiguous is returned if the group structure does not
+// single out h or the certificate fails.
+func ClassNumberImagQuad(D *big.Int) (*big.Int, error) {
+	if D.Sign() >= 0 || new(big.Int).Mod(D, big.NewInt(4)).Int64() > 1 {
+		return nil, ErrNotDiscriminant
+	}
+	if D.CmpAbs(big.NewInt(classNumberBSGSBound)) < 0 {
+		return big.NewInt(classNumberByForms(D.Int64())), nil
+	}
+	lo, hi := classNumberInterval(D)
+
+	// 2^(mu - 1) divides h, where 2^(mu - 1) is the number of genera.
+	factors, err := factorBig(D)
+	if err != nil {
+		return nil, err
+	}
+	E := new(big.Int).Lsh(intOne, uint(genusCharacterCount(D, factors)-1))
+
+	tried := 0
+	for p := int64(2); tried < classNumberBSGSElements; p++ {
+		if count, h := multiplesInInterval(E, lo, hi); count == 0 {
+			return nil, ErrClassNumberAmbiguous
+		} else if count == 1 {
+			if !killsPrimeForms(D, h) {
+				return nil, ErrClassNumberAmbiguous
+			}
+			return h, nil
+		}
+		bp := big.NewInt(p)
+		if !bp.ProbablyPrime(10) || new(big.Int).Mod(D, bp).Sign() == 0 {
+			continue
+		}
+		g := PrimeForm(D, bp)
+		if g == nil {
+			continue
+		}
+		tried++
+		x := g.Pow(E)
+		mlo := new(big.Int).Add(lo, E)
+		mlo.Sub(mlo, intOne).Quo(mlo, E)
+		mhi := new(big.Int).Quo(hi, E)
+		m := bsgsExponent(x, mlo, mhi)
+		if m == nil {
+			return nil, ErrClassNumberAmbiguous
+		}
+		n, err := formOrder(g, m.Mul(m, E))
+		if err != nil {
+			return nil, err
+		}
+		gcd := new(big.Int).GCD(nil, nil, E, n)
+		E.Mul(E, n).Quo(E, gcd)
+	}
+	return nil, ErrClassNumberAmbiguous
+}
+
+// Tell if g^h is principal for every prime form g of discriminant D < 0
+// and norm p <= 6 log^2 |D| prime to D, so that h is a multiple of the exponent of
+// the class group if GRH holds.
+// Bach, Explicit bounds for primality testing, Thm. 4.
+func killsPrimeForms(D, h *big.Int) bool {
+	d, _ := new(big.Float).SetInt(new(big.Int).Neg(D)).Float64()
+	bound := int64(6 * math.Log(d) * math.Log(d))
+	one := PrincipalForm(D)
+	for p := int64(2); p <= bound; p++ {
+		bp := big.NewInt(p)
+		if !bp.ProbablyPrime(10) || new(big.Int).Mod(D, bp).Sign() == 0 {
+			continue
+		}
+		if g := PrimeForm(D, bp); g != nil && !g.Pow(h).Equal(one) {
+			return false
+		}
+	}
+	return true
+}
+
+// Count the reduced primitive forms of discriminant D < 0.
+func classNumberByForms(D int64) int64 {
+	h := int64(0)
+	eachReducedForm(D, func(a, b, c int64) { h++ })
+	return h
+}
+
+// Call visit on each reduced primitive form (a, b, c) of the non-square
+// discriminant D, |D| < 2^62, in the sense of IsReduced. For D < 0 these
+// have |b| <= a <= c, and b >= 0 if either inequality is an equality;
+// for D > 0 they have 0 < b < sqrt(D) and sqrt(D) - b < 2|a| < sqrt(D) + b.
+// Cohen, Alg. 5.3.5 and Sec. 5.6.
+func eachReducedForm(D int64, visit func(a, b, c int64)) {
+	if D < 0 {
+		B := int64(math.Sqrt(float64(-D) / 3))
+		for b := PosMod(D, 2); b <= B; b += 2 {
+			q := (b*b - D) / 4
+			a := b
+			if a < 1 {
+				a = 1
+			}
+			for ; a*a <= q; a++ {
+				if q%a != 0 {
+					continue
+				}
+				c := q / a
+				if gcd64(gcd64(a, b), c) != 1 {
+					continue
+				}
+				visit(a, b, c)
+				if a != b && a != c && b != 0 {
+					visit(a, -b, c)
+				}
+			}
+		}
+		return
+	}
+	s := Sqrt(big.NewInt(D)).Int64()
+	b := int64(2)
+	if D&1 == 1 {
+		b = 1
+	}
+	for ; b <= s; b += 2 {
+		// -ac = (D - b^2) / 4, and ceil((s - b + 1) / 2) <= |a| <= (s + b) / 2.
+		q := (D - b*b) / 4
+		lo := (s - b + 2) / 2
+		if lo < 1 {
+			lo = 1
+		}
+		for a := lo; a <= (s+b)/2; a++ {
+			if q%a != 0 {
+				continue
+			}
+			c := q / a
+			if gcd64(gcd64(a, b), c) != 1 {
+				continue
+			}
+			visit(a, b, -c)
+			visit(-a, b, c)
+		}
+	}
+}
+
+func gcd64(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// Estimate h(D) = sqrt(|D|) L(1, chi_D) / pi for D < -4 by the Euler
+// product up to eulerProductBound, returning an interval around it.
+func classNumberInterval(D *big.Int) (*big.Int, *big.Int) {
+	if eulerProductPrimes == nil {
+		eulerProductPrimes = sievePrimes(eulerProductBound)
+	}
+	logL := 0.0
+	for _, p := range eulerProductPrimes {
+		if chi := Kronecker(D, big.NewInt(p)); chi != 0 {
+			logL -= math.Log(1 - float64(chi)/float64(p))
+		}
+	}
+	d, _ := new(big.Float).SetInt(new(big.Int).Neg(D)).Float64()
+	h := math.Exp(logL+math.Log(d)/2) / math.Pi
+	// The tail of the product is heuristically of size log|D| / sqrt(P).
+	delta := (math.Log(d) + 10) / math.Sqrt(eulerProductBound)
+	lo, _ := new(big.Float).SetFloat64(math.Floor(h * (1 - delta))).Int(nil)
+	hi, _ := new(big.Float).SetFloat64(math.Ceil(h * (1 + delta))).Int(nil)
+	if lo.Sign() <= 0 {
+		lo.SetInt64(1)
+	}
+	return lo, hi
+}
+
+// Count the multiples of E in [lo, hi], up to 2, returning the first.
+func multiplesInInterval(E, lo, hi *big.Int) (int, *big.Int) {
+	m := new(big.Int).Add(lo, E)
+	m.Sub(m, intOne).Quo(m, E).Mul(m, E)
+	if m.Cmp(hi) > 0 {
+		return 0, nil
+	}
+	if new(big.Int).Add(m, E).Cmp(hi) > 0 {
+		return 1, m
+	}
+	return 2, m
+}
+
+// Find m > 0 with x^m the identity, by Shanks' baby-step giant-step
+// method: either the order of x, if the baby steps reach it, or some m
+// in [lo, hi]. Returns nil if there is none.
+func bsgsExponent(x *QuadraticForm, lo, hi *big.Int) *big.Int {
+	D := x.Discriminant()
+	one := PrincipalForm(D)
+	width := new(big.Int).Sub(hi, lo)
+	if width.Sign() < 0 {
+		return nil
+	}
+	s := Sqrt(width.Add(width, intOne))
+	s.Add(s, intOne)
+	steps := int(s.Int64())
+
+	// Baby steps x^j for 0 <= j < s; an early identity gives the order.
+	baby := map[string]int{}
+	y := one
+	for j := 0; j < steps; j++ {
+		if j > 0 && y.Equal(one) {
+			return big.NewInt(int64(j))
+		}
+		if _, ok := baby[y.key()]; !ok {
+			baby[y.key()] = j
+		}
+		y = y.Compose(x)
+	}
+
+	// Giant steps x^-(lo + i s); a match with x^j gives lo + i s + j.
+	step := x.Pow(new(big.Int).Neg(s))
+	z := x.Pow(new(big.Int).Neg(lo))
+	m := new(big.Int).Set(lo)
+	for m.Cmp(hi) <= 0 {
+		if j, ok := baby[z.key()]; ok {
+			return m.Add(m, big.NewInt(int64(j)))
+		}
+		z = z.Compose(step)
+		m.Add(m, s)
+	}
+	return nil
+}
+
+// Compute the order of g, given a multiple n of it.
+func formOrder(g *QuadraticForm, n *big.Int) (*big.Int, error) {
+	factors, err := factorBig(n)
+	if err != nil {
+		return nil, err
+	}
+	one := PrincipalForm(g.Discriminant())
+	order := new(big.Int).Set(n)
+	q, r := new(big.Int), new(big.Int)
+	for _, f := range factors {
+		for i := 0; i < f.exponent; i++ {
+			q.QuoRem(order, f.prime, r)
+			if r.Sign() != 0 || !g.Pow(q).Equal(one) {
+				break
+			}
+			order.Set(q)
+		}
+	}
+	return order, nil
+}
+
+// Compute the number mu of generic characters of the discriminant D, so
+// that there are 2^(mu - 1) genera of forms and as many classes of order
+// dividing 2. The factors are those of |D|.
+// Cox, Prop. 3.11 and Thm. 3.15.
+func genusCharacterCount(D *big.Int, factors []bigFactor) int {
+	r := 0
+	for _, f := range factors {
+		if f.prime.Bit(0) == 1 {
+			r++
+		}
+	}
+	if D.Bit(0) == 1 {
+		return r
+	}
+	n := new(big.Int).Rsh(new(big.Int).Neg(D), 2)
+	switch {
+	case new(big.Int).Mod(n, big.NewInt(8)).Sign() == 0:
+		return r + 2
+	case new(big.Int).Mod(n, big.NewInt(4)).Int64() == 3:
+		return r
+	}
+	return r + 1
+}
+
+// Return the primes up to n by the sieve of Eratosthenes.
+func sievePrimes(n int) []int64 {
+	composite := make([]bool, n+1)
+	ps := []int64{}
+	for i := 2; i <= n; i++ {
+		if composite[i] {
+			continue
+		}
+		ps = append(ps, int64(i))
+		for j := i * i; j <= n; j += i {
+			composite[j] = true
+		}
+	}
+	return ps
+}
@@ -22,65 +22,24 @@ import (
 
 var intOne = big.NewInt(1)
 
-// Compute the class number of an imaginary, quadratic number field.
-// Based on Henri Cohen, _A Course in Algebraic Number Theory_, Alg 5.3.5.
-func classNumberImagQuadSlow(k *NumberField) int {
-	D := k.Discriminant().Int64()
-	D = makeFundamentalDiscriminant(D)
-	h := 1
-	aD := D
-	if aD < 0 {
-		aD = -aD
-	}
-	B := int64(math.Floor(math.Sqrt(float64(aD) / 3.0)))
-
-	for b := PosMod(D, 2); b <= B; b += 2 {
-		q := (b*b - D) / 4
-		a := b
-		if a <= 1 {
-			a = 1
-		}
-		for a == 1 || a*a <= q {
-			if a != 1 && q%a == 0 {
-				if a == b || a*a == q || b == 0 {
-					h++
-				} else {
-					h += 2
-				}
-			}
-			a++
-		}
-	}
-	return h
-}
-
-// Compute the square root of a large number.
-// Uses Newton's Method.
+// Compute the integer square root floor(sqrt(z)) of z >= 0, or nil
+// for z < 0.
 func Sqrt(z *big.Int) *big.Int {
-	if z.BitLen() <= 52 {
-		sqrt := int64(math.Sqrt(float64(z.Int64())))
-		return big.NewInt(sqrt)
-	}
 	if z.Sign() < 0 {
 		return nil
-	} else if z.Sign() == 0 {
-		return big.NewInt(0)
-	} else if z.Cmp(intOne) == 0 {
-		return big.NewInt(1)
 	}
-
-	// initial guess
-	s := big.NewInt(0).Rsh(z, 1)
-	t := big.NewInt(0)
-
-	for i := 0; s.Cmp(t) != 0 && i < 100; i++ {
-		// compute iteration
-		t.Div(z, s)
-		t.Add(t, s)
-		t.Rsh(t, 1)
-		s, t = t, s
+	if z.BitLen() <= 52 {
+		// The rounded square root may be off by one; correct it.
+		s := int64(math.Sqrt(float64(z.Int64())))
+		for s*s > z.Int64() {
+			s--
+		}
+		for (s+1)*(s+1) <= z.Int64() {
+			s++
+		}
+		return big.NewInt(s)
 	}
-	return s
+	return new(big.Int).Sqrt(z)
 }
 
 // Tell if a number is a perfect square.
@@ -0,0 +1,130 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"math/big"
+)
+
+// Compute the class number h(D) of the quadratic order of discriminant
+// D = f^2 d0, for d0 fundamental, from that of the maximal order by the
+// conductor formula h(D) = h(d0) f / [O_d0^* : O_D^*] times the product
+// of 1 - (d0 / p) / p over the primes p | f.
+// ClassNumberImagQuad and ClassNumberRealQuad instead count the primitive
+// forms of discriminant D directly.
+// Cox, Thm. 7.24 and Ex. 7.30; Cohen, Prop. 5.3.12.
+func ClassNumberOrder(D *big.Int) (*big.Int, error) {
+	if D.Sign() > 0 && IsSquare(D) {
+		return nil, ErrNotDiscriminant
+	}
+	d0, f, err := FundamentalDiscriminant(D)
+	if err != nil {
+		return nil, err
+	}
+	var h *big.Int
+	if d0.Sign() < 0 {
+		h, err = ClassNumberImagQuad(d0)
+	} else {
+		h, err = ClassNumberRealQuad(d0)
+	}
+	if err != nil || f.Cmp(intOne) == 0 {
+		return h, err
+	}
+
+	factors, err := factorBig(f)
+	if err != nil {
+		return nil, err
+	}
+	// m = f times the product of 1 - (d0 / p) / p, the order of
+	// (O_d0 / f O_d0)^* / (Z / f Z)^*.
+	m := new(big.Int).Set(f)
+	for _, q := range factors {
+		// Multiply by (p - (d0 / p)) / p.
+		t := new(big.Int).Sub(q.prime, big.NewInt(int64(Kronecker(d0, q.prime))))
+		m.Mul(m, t).Quo(m, q.prime)
+	}
+	index, err := unitIndex(d0, f, m)
+	if err != nil {
+		return nil, err
+	}
+	return h.Mul(h, m).Quo(h, index), nil
+}
+
+// Compute the index of the units of the order of conductor f > 1 in
+// those of the maximal order of discriminant d0. For d0 < 0 it is w / 2;
+// for d0 > 0 it is the order of the fundamental unit e in
+// (O_d0 / f O_d0)^* / (Z / f Z)^*, a group of order m, that is the least
+// n | m with e^n in Z + f O_d0.
+func unitIndex(d0, f, m *big.Int) (*big.Int, error) {
+	if d0.Sign() < 0 {
+		if !d0.IsInt64() {
+			return big.NewInt(1), nil
+		}
+		switch d0.Int64() {
+		case -3:
+			return big.NewInt(3), nil
+		case -4:
+			return big.NewInt(2), nil
+		}
+		return big.NewInt(1), nil
+	}
+	// Write e = (u + v sqrt(d0)) / 2 = a + b w, with w = (d0 + sqrt(d0)) / 2,
+	// so that e^n = a' + b' w is in Z + f O_d0 exactly when f | b'.
+	u, v := fundamentalUnit(d0)
+	a := new(big.Int).Mul(v, d0)
+	a.Sub(u, a).Rsh(a, 1)
+	e := [2]*big.Int{a.Mod(a, f), new(big.Int).Mod(v, f)}
+	factors, err := factorBig(m)
+	if err != nil {
+		return nil, err
+	}
+	n := new(big.Int).Set(m)
+	for _, q := range factors {
+		for i := 0; i < q.exponent; i++ {
+			k := new(big.Int).Quo(n, q.prime)
+			if quadraticPowMod(e, k, d0, f)[1].Sign() != 0 {
+				break
+			}
+			n = k
+		}
+	}
+	return n, nil
+}
+
+// Compute (a + b w)^k mod f in Z[w], w = (d0 + sqrt(d0)) / 2, whose
+// minimal polynomial is w^2 - d0 w + d0 (d0 - 1) / 4.
+func quadraticPowMod(x [2]*big.Int, k, d0, f *big.Int) [2]*big.Int {
+	// N(w) = d0 (d0 - 1) / 4.
+	nw := new(big.Int).Sub(d0, intOne)
+	nw.Mul(nw, d0).Rsh(nw, 2)
+	mul := func(x, y [2]*big.Int) [2]*big.Int {
+		// (a + b w)(c + d w) = ac - bd N(w) + (ad + bc + bd d0) w.
+		bd := new(big.Int).Mul(x[1], y[1])
+		r := new(big.Int).Mul(x[0], y[0])
+		r.Sub(r, new(big.Int).Mul(bd, nw))
+		s := new(big.Int).Mul(x[0], y[1])
+		s.Add(s, new(big.Int).Mul(x[1], y[0])).Add(s, bd.Mul(bd, d0))
+		return [2]*big.Int{r.Mod(r, f), s.Mod(s, f)}
+	}
+	z := [2]*big.Int{new(big.Int).Mod(intOne, f), big.NewInt(0)}
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		z = mul(z, z)
+		if k.Bit(i) == 1 {
+			z = mul(z, x)
+		}
+	}
+	return z
+}
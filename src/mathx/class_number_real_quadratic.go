@@ -0,0 +1,74 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"math/big"
+)
+
+// Compute the class number h(D) of the real quadratic order of
+// discriminant D > 0, D not a square. The reduced primitive forms of
+// discriminant D fall into cycles under Rho, one for each class of forms,
+// so their number is the narrow class number h+(D). The narrow and wide
+// class groups agree when the fundamental unit has norm -1, which happens
+// exactly when (-1, b, c) lies in the principal cycle; otherwise
+// h = h+ / 2. The enumeration takes time linear in D, so this returns
+// ErrClassGroupTooLarge if D is at least classNumberBSGSBound.
+// Cohen, Sec. 5.6 and Alg. 5.7.2.
+func ClassNumberRealQuad(D *big.Int) (*big.Int, error) {
+	if D.Sign() <= 0 || new(big.Int).Mod(D, big.NewInt(4)).Int64() > 1 || IsSquare(D) {
+		return nil, ErrNotDiscriminant
+	}
+	if D.Cmp(big.NewInt(classNumberBSGSBound)) >= 0 {
+		return nil, ErrClassGroupTooLarge
+	}
+	hPlus, normMinusOne := narrowClassNumber(D)
+	h := big.NewInt(int64(hPlus))
+	if !normMinusOne {
+		h.Rsh(h, 1)
+	}
+	return h, nil
+}
+
+// Count the cycles of reduced primitive forms of discriminant D > 0, and
+// tell if the principal cycle contains a form with a = -1.
+func narrowClassNumber(D *big.Int) (int, bool) {
+	seen := map[string]bool{}
+	cycles := 0
+	eachReducedForm(D.Int64(), func(a, b, c int64) {
+		f := NewQuadraticForm64(a, b, c)
+		if seen[f.key()] {
+			return
+		}
+		cycles++
+		g := f
+		for {
+			seen[g.key()] = true
+			g = g.Rho()
+			if g.Equal(f) {
+				break
+			}
+		}
+	})
+
+	principal := PrincipalForm(D).Reduce()
+	for _, g := range principal.Cycle() {
+		if g.a.Cmp(big.NewInt(-1)) == 0 {
+			return cycles, true
+		}
+	}
+	return cycles, false
+}
@@ -17,6 +17,7 @@ package mathx
 
 import (
 	"math"
+	"math/big"
 	"testing"
 )
 
@@ -35,15 +36,50 @@ func TestRegulator(t *testing.T) {
 	}
 }
 
+func TestFundamentalUnit(t *testing.T) {
+	testCases := []struct {
+		polyString string
+		u, v       string
+	}{
+		{"x^2 - 2", "2", "1"},
+		{"x^2 - 5", "1", "1"},
+		{"x^2 - 13", "3", "1"},
+		{"x^2 - 3", "4", "1"},
+		{"x^2 - 94", "4286590", "221064"},
+		{"x^2 - x - 1", "1", "1"},
+	}
+	for _, testCase := range testCases {
+		k := MakeNumberField(ParseIntPoly(testCase.polyString))
+		u, v, err := k.FundamentalUnit()
+		if err != nil || u.String() != testCase.u || v.String() != testCase.v {
+			t.Errorf("%s: expected unit (%s, %s), got (%v, %v) %v", testCase.polyString, testCase.u, testCase.v, u, v, err)
+		}
+	}
+	// A unit far beyond int64, of norm -1 or 1.
+	k := MakeNumberField(ParseIntPoly("x^2 - 1000003"))
+	u, v, err := k.FundamentalUnit()
+	if err != nil || u.BitLen() < 64 {
+		t.Fatalf("x^2 - 1000003: unit (%v, %v) %v", u, v, err)
+	}
+	n := new(big.Int).Mul(u, u)
+	n.Sub(n, new(big.Int).Mul(k.Discriminant(), new(big.Int).Mul(v, v)))
+	if n.Abs(n).Cmp(big.NewInt(4)) != 0 {
+		t.Errorf("x^2 - 1000003: unit (%v, %v) has norm %v / 4", u, v, n)
+	}
+	if _, _, err := MakeNumberField(ParseIntPoly("x^2 + 1")).FundamentalUnit(); err != ErrNotRealQuadratic {
+		t.Errorf("expected ErrNotRealQuadratic, got %v", err)
+	}
+	if _, _, err := MakeNumberField(ParseIntPoly("x^2 - 2*x + 1")).FundamentalUnit(); err != ErrNotSquareFree {
+		t.Errorf("expected ErrNotSquareFree, got %v", err)
+	}
+}
+
 func TestRandomClassNumbers(t *testing.T) {
 	for _, testCase := range classNumberTestCases {
 		p := ParseIntPoly(testCase.polyString)
 		if p.Degree() > 2 {
 			continue
 		}
-		if p.Discriminant().Sign() > 0 {
-			continue
-		}
 		h := testCase.classNumber
 		k := MakeNumberField(p)
 		hGot := k.ClassNumber()
@@ -51,6 +87,23 @@ func TestRandomClassNumbers(t *testing.T) {
 			t.Errorf("%s expected class number %d got %d, discriminant %s\n", p.String(), h, hGot, p.Discriminant().String())
 		}
 	}
+
+	errorCases := []struct {
+		poly string
+		err  error
+	}{
+		{"x^3 - 2", ErrNotQuadratic},
+		{"x^2 + 2*x + 1", ErrNotSquareFree},
+	}
+	for _, c := range errorCases {
+		k := MakeNumberField(ParseIntPoly(c.poly))
+		if _, err := k.ClassNumberBig(); err != c.err {
+			t.Errorf("%s: expected %v, got %v\n", c.poly, c.err, err)
+		}
+		if h := k.ClassNumber(); h != -1 {
+			t.Errorf("%s: expected class number -1, got %d\n", c.poly, h)
+		}
+	}
 }
 
 func TestParsePolynomial(t *testing.T) {
@@ -460,3 +513,224 @@ var classNumberTestCases = []struct {
 	{1, "x^4 + x^3 - 4*x - 1"},
 	{1, "x^4 + 12*x^2 - 2*x + 7"},
 	{1, "x^4 + 2*x^2 + 5*x - 1"}}
+
+var classGroupTestCases = []struct {
+	polyString string
+	group      string
+}{
+	{"x^2 + 1", "C1"},
+	{"x^2 + 5", "C2"},
+	{"x^2 + 23", "C3"},
+	{"x^2 + 14", "C4"},
+	{"x^2 + x + 22", "C6"},
+	{"x^2 + 21", "C2 x C2"},
+	{"x^2 + 65", "C2 x C4"},
+	{"x^2 + 105", "C2 x C2 x C2"},
+	{"2*x^2 + 2*x + 3", "C2"},
+	{"x^2 + x + 825", "C3 x C9"},
+	{"x^2 + x + 1007", "C3 x C3"},
+	{"x^2 + x + 1583", "C18"},
+}
+
+func TestClassGroup(t *testing.T) {
+	for _, testCase := range classGroupTestCases {
+		k := MakeNumberField(ParseIntPoly(testCase.polyString))
+		g, err := k.ClassGroup()
+		if err != nil {
+			t.Errorf("%s: %v", testCase.polyString, err)
+			continue
+		}
+		if g.String() != testCase.group {
+			t.Errorf("%s: expected class group %s, got %s", testCase.polyString, testCase.group, g)
+		}
+		if g.Order() != k.ClassNumber() {
+			t.Errorf("%s: class group order %d, class number %d", testCase.polyString, g.Order(), k.ClassNumber())
+		}
+		// Each generator has exact order d.
+		for i, I := range g.Generators() {
+			d := g.Invariants()[i]
+			if ok, _, _ := I.Pow(d).IsPrincipal(); !ok {
+				t.Errorf("%s: %s^%d is not principal", testCase.polyString, I, d)
+			}
+			for _, q := range Factorization64(int64(d)) {
+				if ok, _, _ := I.Pow(d / int(q.prime)).IsPrincipal(); ok {
+					t.Errorf("%s: %s^%d is principal", testCase.polyString, I, d/int(q.prime))
+				}
+			}
+		}
+	}
+	k := MakeNumberField(ParseIntPoly("x^2 - 5"))
+	if _, err := k.ClassGroup(); err != ErrNotImaginaryQuadratic {
+		t.Errorf("expected ErrNotImaginaryQuadratic, got %v", err)
+	}
+}
+
+func TestClassNumberImagQuad(t *testing.T) {
+	testCases := []struct {
+		D, h int64
+	}{
+		{-3, 1}, {-4, 1}, {-16, 1}, {-23, 3}, {-3299, 27},
+		{-1000003, 105}, {-4000004, 1032}, {-99999991, 5384},
+		{-400000004, 16416},
+	}
+	for _, testCase := range testCases {
+		h, err := ClassNumberImagQuad(big.NewInt(testCase.D))
+		if err != nil || h.Int64() != testCase.h {
+			t.Errorf("h(%d) = %d, got %v (%v)", testCase.D, testCase.h, h, err)
+		}
+	}
+	// Baby-step giant-step against counting forms.
+	for _, D := range []int64{-16777219, -16777232, -20000003} {
+		h, err := ClassNumberImagQuad(big.NewInt(D))
+		if err != nil || h.Int64() != classNumberByForms(D) {
+			t.Errorf("h(%d) = %d, got %v (%v)", D, classNumberByForms(D), h, err)
+		}
+	}
+	// Every class has order dividing h.
+	D, _ := new(big.Int).SetString("-1000000000003", 10)
+	h, err := ClassNumberImagQuad(D)
+	if err != nil {
+		t.Fatalf("h(%s): %v", D, err)
+	}
+	for p := int64(2); p < 100; p++ {
+		if !big.NewInt(p).ProbablyPrime(10) {
+			continue
+		}
+		if f := PrimeForm(D, big.NewInt(p)); f != nil && !f.Pow(h).Equal(PrincipalForm(D)) {
+			t.Errorf("%s^%s is not principal", f, h)
+		}
+	}
+	// The certificate accepts h and rejects a wrong candidate.
+	if !killsPrimeForms(D, h) {
+		t.Errorf("h(%s) = %s fails its certificate", D, h)
+	}
+	if killsPrimeForms(D, new(big.Int).Add(h, big.NewInt(2))) {
+		t.Errorf("h(%s) + 2 passes the certificate", D)
+	}
+	if _, err := ClassNumberImagQuad(big.NewInt(-5)); err != ErrNotDiscriminant {
+		t.Errorf("expected ErrNotDiscriminant, got %v", err)
+	}
+}
+
+func TestClassNumberRealQuad(t *testing.T) {
+	testCases := []struct {
+		D, h, hPlus int64
+	}{
+		{5, 1, 1}, {8, 1, 1}, {12, 1, 2}, {20, 1, 1}, {136, 2, 4},
+		{229, 3, 3}, {316, 3, 6}, {1297 * 4, 11, 11}, {105, 2, 4},
+	}
+	for _, testCase := range testCases {
+		D := big.NewInt(testCase.D)
+		h, err := ClassNumberRealQuad(D)
+		if err != nil || h.Int64() != testCase.h {
+			t.Errorf("h(%d) = %d, got %v (%v)", testCase.D, testCase.h, h, err)
+		}
+		if hPlus, _ := narrowClassNumber(D); int64(hPlus) != testCase.hPlus {
+			t.Errorf("h+(%d) = %d, got %d", testCase.D, testCase.hPlus, hPlus)
+		}
+	}
+	if _, err := ClassNumberRealQuad(big.NewInt(16)); err != ErrNotDiscriminant {
+		t.Errorf("expected ErrNotDiscriminant, got %v", err)
+	}
+	if _, err := ClassNumberRealQuad(big.NewInt(classNumberBSGSBound + 1)); err != ErrClassGroupTooLarge {
+		t.Errorf("expected ErrClassGroupTooLarge, got %v", err)
+	}
+	if _, err := MakeNumberField(ParseIntPoly("x^2 - 1000000007")).ClassNumberBig(); err != ErrClassGroupTooLarge {
+		t.Errorf("x^2 - 1000000007: expected ErrClassGroupTooLarge, got %v", err)
+	}
+}
+
+func TestClassNumberOrder(t *testing.T) {
+	for _, d0 := range []int64{-3, -4, -7, -8, -15, -23, -84, 5, 8, 12, 13, 21, 40} {
+		for f := int64(1); f <= 12; f++ {
+			D := big.NewInt(d0 * f * f)
+			h, err := ClassNumberOrder(D)
+			if err != nil {
+				t.Fatalf("%s: %v", D, err)
+			}
+			var direct *big.Int
+			if d0 < 0 {
+				direct, err = ClassNumberImagQuad(D)
+			} else {
+				direct, err = ClassNumberRealQuad(D)
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", D, err)
+			}
+			if h.Cmp(direct) != 0 {
+				t.Errorf("%s: conductor formula gives %s, forms give %s", D, h, direct)
+			}
+		}
+	}
+
+	// d0 = -(2^64 + 3), whose low 64 bits read as -3.
+	d0 := new(big.Int).Lsh(intOne, 64)
+	d0.Add(d0, big.NewInt(3)).Neg(d0)
+	D := new(big.Int).Lsh(d0, 2)
+	h, err := ClassNumberOrder(D)
+	if err != nil {
+		t.Fatalf("%s: %v", D, err)
+	}
+	if h.Cmp(big.NewInt(2622311334)) != 0 {
+		t.Errorf("%s: expected class number 2622311334, got %s", D, h)
+	}
+
+	// Large conductors of Q(sqrt(5)), where the unit index is the rank of
+	// apparition of f in the Fibonacci numbers: 1000004 for 1000003 and
+	// 1171 for 1000033.
+	testCases := []struct {
+		f string
+		h int64
+	}{
+		{"1000003", 1},
+		{"1000033", 854},
+		{"1000036000099", 854},
+	}
+	for _, c := range testCases {
+		f, _ := new(big.Int).SetString(c.f, 10)
+		D := new(big.Int).Mul(f, f)
+		D.Mul(D, big.NewInt(5))
+		h, err := ClassNumberOrder(D)
+		if err != nil || h.Cmp(big.NewInt(c.h)) != 0 {
+			t.Errorf("%s: expected class number %d, got %v %v", D, c.h, h, err)
+		}
+	}
+}
+
+func TestClassGroupImagQuad(t *testing.T) {
+	testCases := []struct {
+		D     int64
+		group string
+	}{
+		{-12, "C1"}, {-16, "C1"}, {-27, "C1"}, {-108, "C3"}, {-243, "C3"},
+		{-256, "C4"}, {-100, "C2"}, {-3299, "C3 x C9"}, {-3299 * 4, "C9 x C9"},
+	}
+	for _, c := range testCases {
+		D := big.NewInt(c.D)
+		g, err := ClassGroupImagQuad(D)
+		if err != nil {
+			t.Fatalf("%d: %v", c.D, err)
+		}
+		if g.String() != c.group {
+			t.Errorf("%d: expected class group %s, got %s", c.D, c.group, g)
+		}
+		h, _ := ClassNumberOrder(D)
+		if int64(g.Order()) != h.Int64() {
+			t.Errorf("%d: class group order %d, class number %s", c.D, g.Order(), h)
+		}
+		if g.Generators() != nil {
+			t.Errorf("%d: expected no ideals", c.D)
+		}
+		for i, f := range g.Forms() {
+			d := g.Invariants()[i]
+			if !f.Pow(big.NewInt(int64(d))).IsPrincipal() {
+				t.Errorf("%d: %s^%d is not principal", c.D, f, d)
+			}
+			for _, q := range Factorization64(int64(d)) {
+				if f.Pow(big.NewInt(int64(d) / q.prime)).IsPrincipal() {
+					t.Errorf("%d: %s^%d is principal", c.D, f, int64(d)/q.prime)
+				}
+			}
+		}
+	}
+}
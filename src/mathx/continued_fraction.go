@@ -0,0 +1,314 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+var ErrNotQuadraticIrrational = errors.New("mathx: not a quadratic irrationality")
+
+// A simple continued fraction [a_0; a_1, a_2, ...], either finite or
+// eventually periodic: a fixed list of terms followed by a period
+// repeated forever.
+type ContinuedFraction struct {
+	terms  []*big.Int
+	period []*big.Int
+	// For the expansion of a quadratic irrationality, the complete
+	// quotients (P_i + sqrt(D)) / Q_i for the terms and one period.
+	p, q []*big.Int
+}
+
+// Make the continued fraction with the given terms followed by the given
+// period, which is empty for a finite continued fraction.
+func NewContinuedFraction(terms, period []*big.Int) *ContinuedFraction {
+	cf := &ContinuedFraction{}
+	for _, a := range terms {
+		cf.terms = append(cf.terms, new(big.Int).Set(a))
+	}
+	for _, a := range period {
+		cf.period = append(cf.period, new(big.Int).Set(a))
+	}
+	return cf
+}
+
+// Expand a rational number, with the last term larger than 1 unless it
+// is the only one.
+func RatContinuedFraction(x *big.Rat) *ContinuedFraction {
+	cf := &ContinuedFraction{}
+	n, d := new(big.Int).Set(x.Num()), new(big.Int).Set(x.Denom())
+	for d.Sign() != 0 {
+		a, r := new(big.Int), new(big.Int)
+		floorDiv(a, n, d)
+		r.Sub(n, r.Mul(a, d))
+		cf.terms = append(cf.terms, a)
+		n, d = d, r
+	}
+	return cf
+}
+
+// Expand the quadratic irrationality (P + sqrt(D)) / Q, for D > 0 not a
+// square and Q != 0, finding its period. When Q does not divide D - P^2,
+// P, D and Q are first scaled by |Q|, |Q|^2 and |Q|.
+func QuadraticContinuedFraction(P, D, Q *big.Int) (*ContinuedFraction, error) {
+	if D.Sign() <= 0 || Q.Sign() == 0 || IsSquare(D) {
+		return nil, ErrNotQuadraticIrrational
+	}
+	P, D, Q = new(big.Int).Set(P), new(big.Int).Set(D), new(big.Int).Set(Q)
+	t := new(big.Int).Mul(P, P)
+	if t.Sub(D, t).Mod(t, new(big.Int).Abs(Q)).Sign() != 0 {
+		aQ := new(big.Int).Abs(Q)
+		P.Mul(P, aQ)
+		D.Mul(D, aQ).Mul(D, aQ)
+		Q.Mul(Q, aQ)
+	}
+	s := Sqrt(D)
+	cf := &ContinuedFraction{}
+	seen := map[string]int{}
+	for {
+		key := P.String() + " " + Q.String()
+		if i, ok := seen[key]; ok {
+			cf.period = cf.terms[i:]
+			cf.terms = cf.terms[:i]
+			return cf, nil
+		}
+		seen[key] = len(cf.terms)
+		cf.p = append(cf.p, new(big.Int).Set(P))
+		cf.q = append(cf.q, new(big.Int).Set(Q))
+
+		// a = floor((P + sqrt(D)) / Q), where sqrt(D) lies in (s, s + 1);
+		// then P' = a Q - P and Q' = (D - P'^2) / Q.
+		a := new(big.Int).Add(P, s)
+		if Q.Sign() < 0 {
+			a.Add(a, intOne)
+		}
+		floorDiv(a, a, Q)
+		cf.terms = append(cf.terms, a)
+		P.Sub(t.Mul(a, Q), P)
+		Q.Quo(t.Sub(D, t.Mul(P, P)), Q)
+	}
+}
+
+// Set z = floor(x / y) for y != 0.
+func floorDiv(z, x, y *big.Int) *big.Int {
+	r := new(big.Int)
+	z.QuoRem(x, y, r)
+	if r.Sign() != 0 && r.Sign() != y.Sign() {
+		z.Sub(z, intOne)
+	}
+	return z
+}
+
+// Return the terms before the period, or all terms if finite.
+func (cf *ContinuedFraction) Terms() []*big.Int {
+	return copyInts(cf.terms)
+}
+
+// Return the period, which is empty if cf is finite.
+func (cf *ContinuedFraction) Period() []*big.Int {
+	return copyInts(cf.period)
+}
+
+func (cf *ContinuedFraction) IsFinite() bool {
+	return len(cf.period) == 0
+}
+
+// Return the term a_i, or nil past the end of a finite expansion.
+func (cf *ContinuedFraction) Term(i int) *big.Int {
+	if j := cf.index(i); j >= 0 {
+		if j < len(cf.terms) {
+			return new(big.Int).Set(cf.terms[j])
+		}
+		return new(big.Int).Set(cf.period[j-len(cf.terms)])
+	}
+	return nil
+}
+
+// Return P_i and Q_i for the complete quotient (P_i + sqrt(D)) / Q_i of
+// an expansion made by QuadraticContinuedFraction, or nil otherwise.
+func (cf *ContinuedFraction) CompleteQuotient(i int) (*big.Int, *big.Int) {
+	if j := cf.index(i); j >= 0 && j < len(cf.p) {
+		return new(big.Int).Set(cf.p[j]), new(big.Int).Set(cf.q[j])
+	}
+	return nil, nil
+}
+
+// Map i to an index into terms followed by period, or -1.
+func (cf *ContinuedFraction) index(i int) int {
+	if i < 0 || cf.IsFinite() && i >= len(cf.terms) {
+		return -1
+	}
+	if i < len(cf.terms) {
+		return i
+	}
+	return len(cf.terms) + (i-len(cf.terms))%len(cf.period)
+}
+
+// Compute the numerators and denominators of the convergents
+// p_i / q_i for i < n, stopping early at the end of a finite expansion.
+func (cf *ContinuedFraction) convergents(n int) ([]*big.Int, []*big.Int) {
+	ps, qs := []*big.Int{}, []*big.Int{}
+	p0, p1 := big.NewInt(0), big.NewInt(1)
+	q0, q1 := big.NewInt(1), big.NewInt(0)
+	t := new(big.Int)
+	for i := 0; i < n; i++ {
+		a := cf.Term(i)
+		if a == nil {
+			break
+		}
+		p0, p1 = p1, new(big.Int).Add(t.Mul(a, p1), p0)
+		q0, q1 = q1, new(big.Int).Add(t.Mul(a, q1), q0)
+		ps = append(ps, p1)
+		qs = append(qs, q1)
+	}
+	return ps, qs
+}
+
+// Return the convergents p_i / q_i for i < n.
+func (cf *ContinuedFraction) Convergents(n int) []*big.Rat {
+	ps, qs := cf.convergents(n)
+	c := make([]*big.Rat, len(ps))
+	for i := range ps {
+		c[i] = new(big.Rat).SetFrac(ps[i], qs[i])
+	}
+	return c
+}
+
+// Return the convergents and semiconvergents
+// (p_{k-1} + j p_k) / (q_{k-1} + j q_k), 1 <= j <= a_{k+1}, up to the
+// convergent p_{n-1} / q_{n-1}, in order of increasing denominator.
+func (cf *ContinuedFraction) Semiconvergents(n int) []*big.Rat {
+	ps, qs := cf.convergents(n)
+	if len(ps) == 0 {
+		return nil
+	}
+	c := []*big.Rat{new(big.Rat).SetFrac(ps[0], qs[0])}
+	pPrev, qPrev := big.NewInt(1), big.NewInt(0)
+	for k := 0; k+1 < len(ps); k++ {
+		a := cf.Term(k + 1)
+		for j := big.NewInt(1); j.Cmp(a) <= 0; j.Add(j, intOne) {
+			p := new(big.Int).Mul(j, ps[k])
+			q := new(big.Int).Mul(j, qs[k])
+			c = append(c, new(big.Rat).SetFrac(p.Add(p, pPrev), q.Add(q, qPrev)))
+		}
+		pPrev, qPrev = ps[k], qs[k]
+	}
+	return c
+}
+
+// Return the value of a finite continued fraction, or nil.
+func (cf *ContinuedFraction) Value() *big.Rat {
+	if !cf.IsFinite() || len(cf.terms) == 0 {
+		return nil
+	}
+	c := cf.Convergents(len(cf.terms))
+	return c[len(c)-1]
+}
+
+// Compute the best rational approximation p / q of cf with
+// 1 <= q <= N: the closest, and of least denominator among the closest.
+// It is the last convergent p_k / q_k with q_k <= N or the largest
+// semiconvergent (p_{k-1} + j p_k) / (q_{k-1} + j q_k) with denominator
+// at most N, which is closer exactly when the complete quotient x_{k+1}
+// is less than 2j + q_{k-1} / q_k.
+func (cf *ContinuedFraction) BestApproximation(N *big.Int) *big.Rat {
+	if N.Sign() <= 0 {
+		return nil
+	}
+	ps, qs := []*big.Int{}, []*big.Int{}
+	for n := 16; ; n *= 2 {
+		ps, qs = cf.convergents(n)
+		if len(ps) < n || qs[len(qs)-1].Cmp(N) > 0 {
+			break
+		}
+	}
+	k := len(qs) - 1
+	for qs[k].Cmp(N) > 0 {
+		k--
+	}
+	if k == len(qs)-1 {
+		return new(big.Rat).SetFrac(ps[k], qs[k])
+	}
+	pPrev, qPrev := big.NewInt(1), big.NewInt(0)
+	if k > 0 {
+		pPrev, qPrev = ps[k-1], qs[k-1]
+	}
+	j := new(big.Int).Sub(N, qPrev)
+	j.Quo(j, qs[k])
+	r := new(big.Rat).SetFrac(qPrev, qs[k])
+	r.Add(r, new(big.Rat).SetInt(new(big.Int).Lsh(j, 1)))
+	if j.Sign() == 0 || cf.compareTail(k+1, r) >= 0 {
+		return new(big.Rat).SetFrac(ps[k], qs[k])
+	}
+	p := new(big.Int).Mul(j, ps[k])
+	q := new(big.Int).Mul(j, qs[k])
+	return new(big.Rat).SetFrac(p.Add(p, pPrev), q.Add(q, qPrev))
+}
+
+// Compare the complete quotient [a_i; a_{i+1}, ...] with r, term by term;
+// a larger term at an even depth makes the value larger, and at an odd
+// depth smaller.
+func (cf *ContinuedFraction) compareTail(i int, r *big.Rat) int {
+	rt := RatContinuedFraction(r).terms
+	sign := 1
+	for t := 0; ; t++ {
+		a := cf.Term(i + t)
+		switch {
+		case a == nil && t >= len(rt):
+			return 0
+		case a == nil:
+			// x ends first, so at depth t - 1 its quotient is smaller.
+			return sign
+		case t >= len(rt):
+			return -sign
+		}
+		if c := a.Cmp(rt[t]); c != 0 {
+			return c * sign
+		}
+		sign = -sign
+	}
+}
+
+func (cf *ContinuedFraction) String() string {
+	s := []string{}
+	for _, a := range cf.terms {
+		s = append(s, a.String())
+	}
+	if !cf.IsFinite() {
+		p := []string{}
+		for _, a := range cf.period {
+			p = append(p, a.String())
+		}
+		s = append(s, "("+strings.Join(p, ", ")+")")
+	}
+	if len(s) == 0 {
+		return "[]"
+	}
+	if len(s) == 1 {
+		return "[" + s[0] + "]"
+	}
+	return "[" + s[0] + "; " + strings.Join(s[1:], ", ") + "]"
+}
+
+func copyInts(a []*big.Int) []*big.Int {
+	b := make([]*big.Int, len(a))
+	for i := range a {
+		b[i] = new(big.Int).Set(a[i])
+	}
+	return b
+}
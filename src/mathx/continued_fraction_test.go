@@ -0,0 +1,129 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"math/big"
+	"testing"
+)
+
+func quadraticCF(P, D, Q int64) *ContinuedFraction {
+	cf, err := QuadraticContinuedFraction(big.NewInt(P), big.NewInt(D), big.NewInt(Q))
+	if err != nil {
+		panic(err)
+	}
+	return cf
+}
+
+func TestContinuedFractionExpansion(t *testing.T) {
+	testCases := []struct {
+		cf     *ContinuedFraction
+		expect string
+	}{
+		{RatContinuedFraction(big.NewRat(415, 93)), "[4; 2, 6, 7]"},
+		{RatContinuedFraction(big.NewRat(-7, 3)), "[-3; 1, 2]"},
+		{RatContinuedFraction(big.NewRat(5, 1)), "[5]"},
+		{quadraticCF(0, 7, 1), "[2; (1, 1, 1, 4)]"},
+		{quadraticCF(1, 5, 2), "[(1)]"},
+		{quadraticCF(0, 94, 1), "[9; (1, 2, 3, 1, 1, 5, 1, 8, 1, 5, 1, 1, 3, 2, 1, 18)]"},
+		{quadraticCF(3, 2, 5), "[0; 1, 7, (1, 1, 6)]"},
+		{quadraticCF(-2, 13, -3), "[-1; 2, (6, 1, 1, 1, 1)]"},
+	}
+	for _, c := range testCases {
+		if c.cf.String() != c.expect {
+			t.Errorf("expected %s, got %s", c.expect, c.cf)
+		}
+	}
+
+	if _, err := QuadraticContinuedFraction(big.NewInt(1), big.NewInt(9), big.NewInt(2)); err != ErrNotQuadraticIrrational {
+		t.Errorf("expected an error for a square D")
+	}
+}
+
+func TestContinuedFractionConvergents(t *testing.T) {
+	cf := quadraticCF(0, 2, 1)
+	expect := []string{"1/1", "3/2", "7/5", "17/12", "41/29"}
+	for i, c := range cf.Convergents(5) {
+		if c.String() != expect[i] {
+			t.Errorf("convergent %d: expected %s, got %s", i, expect[i], c)
+		}
+	}
+	expect = []string{"1/1", "2/1", "3/2", "4/3", "7/5", "10/7", "17/12"}
+	semi := cf.Semiconvergents(4)
+	if len(semi) != len(expect) {
+		t.Fatalf("expected %d semiconvergents, got %d", len(expect), len(semi))
+	}
+	for i, c := range semi {
+		if c.String() != expect[i] {
+			t.Errorf("semiconvergent %d: expected %s, got %s", i, expect[i], c)
+		}
+	}
+
+	x := big.NewRat(415, 93)
+	if v := RatContinuedFraction(x).Value(); v.Cmp(x) != 0 {
+		t.Errorf("expected %s, got %s", x, v)
+	}
+}
+
+// Find the best approximation with denominator at most N by brute force.
+func bestApproximationSlow(x *big.Rat, N int64) *big.Rat {
+	var best, bestErr *big.Rat
+	for q := int64(1); q <= N; q++ {
+		for _, d := range []int64{0, 1} {
+			p := new(big.Int).Mul(x.Num(), big.NewInt(q))
+			floorDiv(p, p, x.Denom())
+			r := new(big.Rat).SetFrac(p.Add(p, big.NewInt(d)), big.NewInt(q))
+			e := new(big.Rat).Sub(x, r)
+			e.Abs(e)
+			if best == nil || e.Cmp(bestErr) < 0 {
+				best, bestErr = r, e
+			}
+		}
+	}
+	return best
+}
+
+func TestContinuedFractionBestApproximation(t *testing.T) {
+	pi, _ := new(big.Rat).SetString("3.14159265358979323846")
+	cf := RatContinuedFraction(pi)
+	for _, c := range []struct {
+		N      int64
+		expect string
+	}{{1, "3/1"}, {7, "22/7"}, {100, "311/99"}, {1000, "355/113"}, {30000, "94053/29938"}} {
+		if b := cf.BestApproximation(big.NewInt(c.N)); b.String() != c.expect {
+			t.Errorf("N = %d: expected %s, got %s", c.N, c.expect, b)
+		}
+	}
+
+	for _, x := range []*big.Rat{big.NewRat(415, 93), big.NewRat(-1000, 1001), big.NewRat(17, 12), big.NewRat(5, 2)} {
+		cf := RatContinuedFraction(x)
+		for N := int64(1); N <= 40; N++ {
+			if b, slow := cf.BestApproximation(big.NewInt(N)), bestApproximationSlow(x, N); b.Cmp(slow) != 0 {
+				t.Errorf("%s, N = %d: expected %s, got %s", x, N, slow, b)
+			}
+		}
+	}
+	for _, D := range []int64{2, 3, 7, 13, 94} {
+		cf := quadraticCF(0, D, 1)
+		c := cf.Convergents(60)
+		x := c[len(c)-1]
+		for N := int64(1); N <= 40; N++ {
+			if b, slow := cf.BestApproximation(big.NewInt(N)), bestApproximationSlow(x, N); b.Cmp(slow) != 0 {
+				t.Errorf("sqrt(%d), N = %d: expected %s, got %s", D, N, slow, b)
+			}
+		}
+	}
+}
@@ -25,20 +25,19 @@ type factor64 struct {
 	exponent int
 }
 
-func (k *NumberField) Discriminant() *big.Int {
-	return k.polynomial.Discriminant()
-}
-
+// Compute the discriminant of a polynomial of degree n >= 1,
+// (-1)^(n(n-1)/2) Res(p, p') / lc(p). Returns nil for constants.
 func (p *IntPolynomial) Discriminant() *big.Int {
-	if p.Degree() == 2 {
-		c, b, a := p.coeffs[0], p.coeffs[1], p.coeffs[2]
-		b2 := big.NewInt(0).Mul(&b, &b)
-		ac4 := big.NewInt(4)
-		ac4 = ac4.Mul(ac4, &a)
-		ac4 = ac4.Mul(ac4, &c)
-		return b2.Sub(b2, ac4)
-	}
-	return nil
+	n := p.Degree()
+	if n < 1 {
+		return nil
+	}
+	d := p.Resultant(p.Derivative())
+	d.Quo(d, &p.coeffs[n])
+	if (n*(n-1)/2)&1 == 1 {
+		d.Neg(d)
+	}
+	return d
 }
 
 var primes = []int64{2, 3}
@@ -63,9 +62,6 @@ func genPrimes(n int64) {
 }
 
 func Factorization64(n int64) []factor64 {
-	sqrtN := int64(math.Floor(math.Sqrt(float64(n))))
-	genPrimes(sqrtN)
-
 	factors := []factor64{}
 	if n < 0 {
 		factors = append(factors, factor64{-1, 1})
@@ -74,6 +70,9 @@ func Factorization64(n int64) []factor64 {
 	if n <= 1 {
 		return factors
 	}
+	sqrtN := int64(math.Floor(math.Sqrt(float64(n))))
+	genPrimes(sqrtN)
+
 	for _, p := range primes {
 		x := 0
 		for ; n%p == 0; x++ {
@@ -82,6 +81,13 @@ func Factorization64(n int64) []factor64 {
 		if x > 0 {
 			factors = append(factors, factor64{p, x})
 		}
+		if p*p > n {
+			break
+		}
+	}
+	// Whatever is left has no factor below its square root.
+	if n > 1 {
+		factors = append(factors, factor64{n, 1})
 	}
 	return factors
 }
@@ -105,37 +111,51 @@ func PosMod(a, b int64) int64 {
 	return m
 }
 
-func IsFundamentalDiscriminant(D *big.Int) bool {
-	d := D.Int64()
-	absd := d
-	if d < 0 {
-		absd = -absd
-	}
-	if d == 1 {
-		return true
+// Tell if n is square-free, factoring it completely; returns
+// ErrFactorizationFailed if |n| cannot be factored.
+func IsSquareFree(n *big.Int) (bool, error) {
+	if n.Sign() == 0 {
+		return false, nil
 	}
-	if PosMod(d, 4) == 1 && IsSquareFree64(absd) {
-		return true
+	factors, err := factorBig(n)
+	if err != nil {
+		return false, err
 	}
-	if PosMod(d, 4) == 0 {
-		if IsSquareFree64(absd / 4) {
-			return PosMod(d/4, 4) == 2 || PosMod(d/4, 4) == 3
+	for _, f := range factors {
+		if f.exponent >= 2 {
+			return false, nil
 		}
 	}
-	return false
+	return true, nil
 }
 
-func makeFundamentalDiscriminant(D int64) int64 {
-	if IsFundamentalDiscriminant(big.NewInt(D)) {
-		return D
+// Write the discriminant D = 0, 1 mod 4, D != 0, as D = d0 f^2 with d0
+// a fundamental discriminant and f > 0. d0 is the square-free part of D,
+// times 4 unless it is 1 mod 4.
+func FundamentalDiscriminant(D *big.Int) (*big.Int, *big.Int, error) {
+	if D.Sign() == 0 || new(big.Int).Mod(D, big.NewInt(4)).Int64() > 1 {
+		return nil, nil, ErrNotDiscriminant
 	}
-	factors := Factorization64(D)
+	factors, err := factorBig(D)
+	if err != nil {
+		return nil, nil, err
+	}
+	d0 := big.NewInt(int64(D.Sign()))
 	for _, f := range factors {
-		ex := f.exponent
-		for ex >= 2 {
-			D = D / (f.prime * f.prime)
-			ex -= 2
+		if f.exponent&1 == 1 {
+			d0.Mul(d0, f.prime)
 		}
 	}
-	return D
+	if new(big.Int).Mod(d0, big.NewInt(4)).Int64() != 1 {
+		d0.Lsh(d0, 2)
+	}
+	f := Sqrt(new(big.Int).Quo(D, d0))
+	return d0, f, nil
+}
+
+// Tell if D is a fundamental discriminant; false also when D cannot be
+// factored.
+func IsFundamentalDiscriminant(D *big.Int) bool {
+	_, f, err := FundamentalDiscriminant(D)
+	return err == nil && f.Cmp(intOne) == 0
 }
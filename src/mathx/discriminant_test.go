@@ -28,7 +28,109 @@ func TestFundamentalDiscriminantsSmall(t *testing.T) {
 		a := IsFundamentalDiscriminant(big.NewInt(int64(d)))
 		b := good.Contains(d)
 		if a != b {
-			t.Errorf("Fundamental discriminant failed for ", d)
+			t.Errorf("Fundamental discriminant failed for %d", d)
 		}
 	}
 }
+
+func TestFundamentalDiscriminant(t *testing.T) {
+	p, _ := new(big.Int).SetString("2305843009213693951", 10)
+	p2 := new(big.Int).Mul(p, p)
+	testCases := []struct {
+		D, d0, f *big.Int
+	}{
+		{big.NewInt(-16), big.NewInt(-4), big.NewInt(2)},
+		{big.NewInt(-300), big.NewInt(-3), big.NewInt(10)},
+		{big.NewInt(72), big.NewInt(8), big.NewInt(3)},
+		{big.NewInt(12), big.NewInt(12), big.NewInt(1)},
+		{big.NewInt(9), big.NewInt(1), big.NewInt(3)},
+		{new(big.Int).Mul(p2, big.NewInt(-4)), big.NewInt(-4), p},
+		{new(big.Int).Mul(p2, big.NewInt(-7)), big.NewInt(-7), p},
+		{new(big.Int).Mul(p, big.NewInt(-4)), new(big.Int).Neg(p), big.NewInt(2)},
+		{new(big.Int).Mul(p, big.NewInt(4)), new(big.Int).Mul(p, big.NewInt(4)), big.NewInt(1)},
+		{new(big.Int).Lsh(big.NewInt(-3), 300), big.NewInt(-3), new(big.Int).Lsh(intOne, 150)},
+		{new(big.Int).Lsh(big.NewInt(5), 400), big.NewInt(5), new(big.Int).Lsh(intOne, 200)},
+	}
+	for _, c := range testCases {
+		d0, f, err := FundamentalDiscriminant(c.D)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d0.Cmp(c.d0) != 0 || f.Cmp(c.f) != 0 {
+			t.Errorf("%s: expected (%s, %s), got (%s, %s)", c.D, c.d0, c.f, d0, f)
+		}
+		if IsFundamentalDiscriminant(c.D) != (c.f.Cmp(intOne) == 0) {
+			t.Errorf("%s: wrong IsFundamentalDiscriminant", c.D)
+		}
+	}
+	for _, D := range []int64{0, 2, -5, 7} {
+		if _, _, err := FundamentalDiscriminant(big.NewInt(D)); err != ErrNotDiscriminant {
+			t.Errorf("%d: expected ErrNotDiscriminant, got %v", D, err)
+		}
+	}
+
+	if ok, err := IsSquareFree(new(big.Int).Mul(p, big.NewInt(-30))); !ok || err != nil {
+		t.Errorf("expected -30p to be square-free")
+	}
+	if ok, err := IsSquareFree(new(big.Int).Mul(p2, big.NewInt(3))); ok || err != nil {
+		t.Errorf("expected 3p^2 not to be square-free")
+	}
+}
+
+func TestFactorization64(t *testing.T) {
+	testCases := []struct {
+		n       int64
+		factors []factor64
+	}{
+		{1, nil},
+		{14, []factor64{{2, 1}, {7, 1}}},
+		{-12, []factor64{{-1, 1}, {2, 2}, {3, 1}}},
+		{2 * 1000000007, []factor64{{2, 1}, {1000000007, 1}}},
+		{9 * 1000000007, []factor64{{3, 2}, {1000000007, 1}}},
+		{1009 * 1013, []factor64{{1009, 1}, {1013, 1}}},
+	}
+	for _, c := range testCases {
+		got := Factorization64(c.n)
+		ok := len(got) == len(c.factors)
+		for i := 0; ok && i < len(got); i++ {
+			ok = got[i] == c.factors[i]
+		}
+		if !ok {
+			t.Errorf("%d: expected factors %v, got %v", c.n, c.factors, got)
+		}
+	}
+}
+
+func TestSqrtSmall(t *testing.T) {
+	for _, n := range []int64{0, 1, 3, 4, 1<<52 - 1, 1 << 50, 1<<50 - 1} {
+		s := Sqrt(big.NewInt(n)).Int64()
+		if s*s > n || (s+1)*(s+1) <= n {
+			t.Errorf("wrong square root %d of %d", s, n)
+		}
+	}
+}
+
+func TestSqrtLarge(t *testing.T) {
+	for _, bits := range []uint{53, 199, 200, 256, 400, 1000} {
+		for _, delta := range []int64{-1, 0, 1} {
+			n := new(big.Int).Lsh(big.NewInt(3), bits-2)
+			n.Add(n, big.NewInt(delta))
+			s := Sqrt(n)
+			lo := new(big.Int).Mul(s, s)
+			hi := new(big.Int).Add(s, intOne)
+			hi.Mul(hi, hi)
+			if lo.Cmp(n) > 0 || hi.Cmp(n) <= 0 {
+				t.Errorf("wrong square root %s of %s", s, n)
+			}
+		}
+	}
+	r := new(big.Int).Lsh(intOne, 150)
+	r.Add(r, big.NewInt(7))
+	n := new(big.Int).Mul(r, r)
+	if !IsSquare(n) || Sqrt(n).Cmp(r) != 0 {
+		t.Errorf("expected (2^150 + 7)^2 to be the square of %s, got %s", r, Sqrt(n))
+	}
+	if IsSquare(n.Add(n, intOne)) {
+		t.Errorf("expected (2^150 + 7)^2 + 1 not to be a square")
+	}
+}
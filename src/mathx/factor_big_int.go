@@ -0,0 +1,157 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+)
+
+const (
+	// Trial divide by primes up to this bound before using Pollard rho.
+	trialDivisionBound = 10000
+	// Give up on Pollard rho after this many iterations per attempt.
+	rhoIterations = 1 << 22
+	rhoAttempts   = 8
+)
+
+var ErrFactorizationFailed = errors.New("mathx: unable to factor integer")
+
+type bigFactor struct {
+	prime    *big.Int
+	exponent int
+}
+
+// Factor |n| into primes, in increasing order, by trial division and
+// Pollard's rho method with Brent's cycle detection. Returns
+// ErrFactorizationFailed if a composite part resists factoring.
+func factorBig(n *big.Int) ([]bigFactor, error) {
+	m := new(big.Int).Abs(n)
+	factors := []bigFactor{}
+	if m.Sign() == 0 {
+		return factors, nil
+	}
+	genPrimes(trialDivisionBound)
+	q := big.NewInt(0)
+	r := big.NewInt(0)
+	for _, p := range primes {
+		if p > trialDivisionBound {
+			break
+		}
+		bp := big.NewInt(p)
+		e := 0
+		for {
+			q.QuoRem(m, bp, r)
+			if r.Sign() != 0 {
+				break
+			}
+			m.Set(q)
+			e++
+		}
+		if e > 0 {
+			factors = append(factors, bigFactor{bp, e})
+		}
+		if m.Cmp(intOne) == 0 {
+			return factors, nil
+		}
+	}
+
+	stack := []*big.Int{m}
+	large := []*big.Int{}
+	for len(stack) > 0 {
+		x := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if x.Cmp(intOne) == 0 {
+			continue
+		}
+		if x.ProbablyPrime(20) {
+			large = append(large, x)
+			continue
+		}
+		d := pollardRho(x)
+		if d == nil {
+			return nil, ErrFactorizationFailed
+		}
+		stack = append(stack, d, new(big.Int).Quo(x, d))
+	}
+	sort.Sort(bigInts(large))
+	for _, p := range large {
+		k := len(factors) - 1
+		if k >= 0 && factors[k].prime.Cmp(p) == 0 {
+			factors[k].exponent++
+		} else {
+			factors = append(factors, bigFactor{p, 1})
+		}
+	}
+	return factors, nil
+}
+
+// Find a nontrivial factor of the composite n, or nil.
+// Brent's variant of Pollard rho, with x -> x^2 + c.
+func pollardRho(n *big.Int) *big.Int {
+	if n.Bit(0) == 0 {
+		return big.NewInt(2)
+	}
+	if s := Sqrt(n); new(big.Int).Mul(s, s).Cmp(n) == 0 {
+		return s
+	}
+	for c := int64(1); c <= rhoAttempts; c++ {
+		bc := big.NewInt(c)
+		y := big.NewInt(2)
+		x := new(big.Int)
+		g := big.NewInt(1)
+		q := big.NewInt(1)
+		t := new(big.Int)
+		ys := new(big.Int)
+		for r := 1; g.Cmp(intOne) == 0 && r <= rhoIterations; r <<= 1 {
+			x.Set(y)
+			for i := 0; i < r; i++ {
+				y.Mul(y, y).Add(y, bc).Mod(y, n)
+			}
+			for k := 0; k < r && g.Cmp(intOne) == 0; k += 128 {
+				ys.Set(y)
+				for i := 0; i < 128 && i < r-k; i++ {
+					y.Mul(y, y).Add(y, bc).Mod(y, n)
+					t.Sub(x, y).Abs(t)
+					q.Mul(q, t).Mod(q, n)
+				}
+				g.GCD(nil, nil, q, n)
+			}
+		}
+		if g.Cmp(n) == 0 {
+			// Backtrack one step at a time from the last saved point.
+			for {
+				ys.Mul(ys, ys).Add(ys, bc).Mod(ys, n)
+				t.Sub(x, ys).Abs(t)
+				g.GCD(nil, nil, t, n)
+				if g.Cmp(intOne) != 0 {
+					break
+				}
+			}
+		}
+		if g.Cmp(intOne) != 0 && g.Cmp(n) != 0 {
+			return g
+		}
+	}
+	return nil
+}
+
+type bigInts []*big.Int
+
+func (a bigInts) Len() int           { return len(a) }
+func (a bigInts) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a bigInts) Less(i, j int) bool { return a[i].Cmp(a[j]) < 0 }
@@ -0,0 +1,227 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package float
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	. "mathx"
+	"strings"
+)
+
+var ErrComplexSyntax = errors.New("mathx: invalid complex number syntax")
+
+// A complex number re + i im. Both parts are kept at the precision of
+// the number, so that a zero part does not lower it.
+type Complex struct {
+	re, im    *Float
+	precision uint64
+}
+
+// Make the complex number re + i im, at the lower of the precisions of
+// re and im.
+func NewComplex(re, im *Float) *Complex {
+	precision := re.precision
+	if im.precision < precision {
+		precision = im.precision
+	}
+	return newComplex(re, im, precision)
+}
+
+func newComplex(re, im *Float, precision uint64) *Complex {
+	return &Complex{re.SetPrecision(precision), im.SetPrecision(precision), precision}
+}
+
+// Convert a real number to a complex number.
+func NewComplexFloat(x *Float) *Complex {
+	return newComplex(x, NewFloatInt(NewInt(0), x.precision), x.precision)
+}
+
+func (z *Complex) Real() *Float {
+	return z.re.Copy()
+}
+
+func (z *Complex) Imag() *Float {
+	return z.im.Copy()
+}
+
+func (z *Complex) Precision() uint64 {
+	return z.precision
+}
+
+func (z *Complex) IsZero() bool {
+	return z.re.mantissa.Sign() == 0 && z.im.mantissa.Sign() == 0
+}
+
+func (z *Complex) minPrecision(w *Complex) uint64 {
+	if w.precision < z.precision {
+		return w.precision
+	}
+	return z.precision
+}
+
+func (z *Complex) Add(w *Complex) *Complex {
+	return newComplex(z.re.Add(w.re), z.im.Add(w.im), z.minPrecision(w))
+}
+
+func (z *Complex) Sub(w *Complex) *Complex {
+	return newComplex(z.re.Sub(w.re), z.im.Sub(w.im), z.minPrecision(w))
+}
+
+func (z *Complex) Mul(w *Complex) *Complex {
+	re := z.re.Mul(w.re).Sub(z.im.Mul(w.im))
+	im := z.re.Mul(w.im).Add(z.im.Mul(w.re))
+	return newComplex(re, im, z.minPrecision(w))
+}
+
+// Compute z / w = z conj(w) / |w|^2.
+func (z *Complex) Div(w *Complex) *Complex {
+	if w.IsZero() {
+		panic("division by zero is undefined\n")
+	}
+	n := w.re.Mul(w.re).Add(w.im.Mul(w.im))
+	re := z.re.Mul(w.re).Add(z.im.Mul(w.im))
+	im := z.im.Mul(w.re).Sub(z.re.Mul(w.im))
+	return newComplex(re.Div(n), im.Div(n), z.minPrecision(w))
+}
+
+func (z *Complex) Neg() *Complex {
+	return newComplex(z.re.Neg(), z.im.Neg(), z.precision)
+}
+
+func (z *Complex) Conj() *Complex {
+	return newComplex(z.re, z.im.Neg(), z.precision)
+}
+
+// Compute |z| = sqrt(re^2 + im^2).
+func (z *Complex) Abs() *Float {
+	return z.re.Mul(z.re).Add(z.im.Mul(z.im)).Sqrt().SetPrecision(z.precision)
+}
+
+// Compute the argument of z in (-pi, pi], which is 0 for z = 0.
+func (z *Complex) Arg() *Float {
+	return Atan2(z.im, z.re)
+}
+
+// Compute the principal square root, with a non-negative real part: for
+// re >= 0 it is u + i im / 2u with u = sqrt((|z| + re) / 2), and for
+// re < 0 it is |im| / 2v + i sign(im) v with v = sqrt((|z| - re) / 2),
+// which avoids cancellation.
+func (z *Complex) Sqrt() *Complex {
+	if z.IsZero() {
+		return newComplex(z.re, z.im, z.precision)
+	}
+	two := NewFloatInt(NewInt(2), z.precision)
+	r := z.Abs()
+	if z.re.sign || z.re.mantissa.Sign() == 0 {
+		u := r.Add(z.re).Div(two).Sqrt().SetPrecision(z.precision)
+		return newComplex(u, z.im.Div(u.Mul(two)), z.precision)
+	}
+	v := r.Sub(z.re).Div(two).Sqrt().SetPrecision(z.precision)
+	if !z.im.sign && z.im.mantissa.Sign() != 0 {
+		v = v.Neg()
+	}
+	return newComplex(z.im.Div(v.Mul(two)), v, z.precision)
+}
+
+// Compute e^z = e^re (cos im + i sin im).
+func (z *Complex) Exp() *Complex {
+	m := z.re.Exp()
+	return newComplex(m.Mul(z.im.Cos()), m.Mul(z.im.Sin()), z.precision)
+}
+
+// Compute the principal logarithm log |z| + i Arg(z).
+func (z *Complex) Log() *Complex {
+	if z.IsZero() {
+		panic("logarithm of zero is undefined\n")
+	}
+	n := z.re.Mul(z.re).Add(z.im.Mul(z.im)).SetPrecision(z.precision)
+	re := n.Log().Div(NewFloatInt(NewInt(2), z.precision))
+	return newComplex(re, z.Arg(), z.precision)
+}
+
+// Compute the principal power z^w = e^(w log z), with 0^w = 0 for
+// Re(w) > 0 and z^0 = 1.
+func (z *Complex) Pow(w *Complex) *Complex {
+	precision := z.minPrecision(w)
+	if w.IsZero() {
+		return NewComplexFloat(NewFloatInt(NewInt(1), precision))
+	}
+	if z.IsZero() {
+		if !w.re.sign || w.re.mantissa.Sign() == 0 {
+			panic("zero to a power with non-positive real part is undefined\n")
+		}
+		return NewComplexFloat(NewFloatInt(NewInt(0), precision))
+	}
+	return w.Mul(z.Log()).Exp()
+}
+
+// Parse a complex number in the form of strconv.ParseComplex, "a",
+// "bi" or "a+bi" with optional parentheses, where a and b are decimal
+// numbers, possibly with an exponent, or fractions, and "i" stands for
+// "1i". Whitespace is allowed only around the number.
+func ParseComplex(s string, precision uint64) (*Complex, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '(' && s[len(s)-1] == ')' {
+		s = s[1 : len(s)-1]
+	}
+	re, im := s, "0"
+	if strings.HasSuffix(s, "i") {
+		// Split at the last sign that does not start an exponent.
+		split := 0
+		for i := len(s) - 2; i > 0; i-- {
+			if (s[i] == '+' || s[i] == '-') && s[i-1] != 'e' && s[i-1] != 'E' {
+				split = i
+				break
+			}
+		}
+		re, im = s[:split], s[split:len(s)-1]
+		if re == "" {
+			re = "0"
+		}
+		switch im {
+		case "", "+":
+			im = "1"
+		case "-":
+			im = "-1"
+		}
+	}
+	a, ok := new(big.Rat).SetString(re)
+	if !ok {
+		return nil, ErrComplexSyntax
+	}
+	b, ok := new(big.Rat).SetString(im)
+	if !ok {
+		return nil, ErrComplexSyntax
+	}
+	return newComplex(NewFloatRat(a, precision), NewFloatRat(b, precision), precision), nil
+}
+
+// Format z as "(a+bi)", with as many significant digits in a and b as
+// its precision gives.
+func (z *Complex) String() string {
+	digits := int(float64(z.precision) * math.Log10(2))
+	im := formatFloat(z.im, z.precision, digits)
+	if im[0] != '-' {
+		im = "+" + im
+	}
+	return "(" + formatFloat(z.re, z.precision, digits) + im + "i)"
+}
+
+func formatFloat(x *Float, precision uint64, digits int) string {
+	return new(big.Float).SetPrec(uint(2*precision)).SetRat(x.Rat()).Text('g', digits)
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package float
+
+import (
+	"math/big"
+	. "mathx"
+)
+
+// Return x as an exact rational number.
+func (x *Float) Rat() *big.Rat {
+	m := new(big.Int).Set((*big.Int)(x.mantissa))
+	if !x.sign {
+		m.Neg(m)
+	}
+	z := new(big.Rat)
+	if x.exp >= 0 {
+		return z.SetInt(m.Lsh(m, uint(x.exp)))
+	}
+	return z.SetFrac(m, new(big.Int).Lsh(big.NewInt(1), uint(-x.exp)))
+}
+
+// Expand x as a continued fraction, keeping the terms that its precision
+// determines: those whose convergents p_k / q_k have
+// q_k^2 <= 2^precision / (|x| + 1), since an error e in x moves the
+// expansion only past the convergents with q_k^2 e >= 1 / 2.
+func (x *Float) ContinuedFraction() *ContinuedFraction {
+	all := RatContinuedFraction(x.Rat())
+	terms := all.Terms()
+	bound := int(x.precision) - 1
+	if x.mantissa.Sign() != 0 {
+		if e := x.mantissa.BitLen() + int(x.exp); e > 0 {
+			bound -= e
+		}
+	}
+	n := 0
+	for k, c := range all.Convergents(len(terms)) {
+		if k > 0 && 2*c.Denom().BitLen() > bound {
+			break
+		}
+		n = k + 1
+	}
+	return NewContinuedFraction(terms[:n], nil)
+}
@@ -214,11 +214,11 @@ func (_z *Float) Sqrt() *Float {
 	accuracy.exp = accuracy.exp - int64(accuracy.precision) //this will make sure that the loop compares z^2 to accuracy^2
 	number.precision = 2 * number.precision
 	z := NewFloat(1.0)
-	z.precision = 2 * z.precision
+	z.precision = number.precision
 	two := NewFloat(2.0)
 	two.precision = number.precision
 	denominator := NewFloat(1.0)
-	denominator.precision = 2 * denominator.precision
+	denominator.precision = number.precision
 	delta := z.Mul(z).Sub(number).Abs()
 	for delta.Cmp(accuracy) == 1 { //if the difference between the correct answer and the current guess is larger than the required accuracy, repeat
 		prez := z
@@ -14,6 +14,8 @@ package float
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	. "mathx"
 	"testing"
 )
@@ -234,6 +236,25 @@ func TestFloatSqrt(t *testing.T) {
 	}
 }
 
+func TestFloatSqrtPrecision(t *testing.T) {
+	// sqrt(2) and sqrt(2^200 + 1) to 256 bits.
+	testCases := []struct {
+		x    *Int
+		root string
+	}{
+		{NewInt(2), "1.414213562373095048801688724209698078569671875376948073176679737990732478462107038850387534327641572735"},
+		{NewInt(1).Lsh(200).Add64(1), "1267650600228229401496703205376.000000000000000000000000000000394430452610505902705864282641393114836603217554"},
+	}
+	for _, testCase := range testCases {
+		root, _ := new(big.Rat).SetString(testCase.root)
+		x := NewFloatInt(testCase.x, 256)
+		d := x.Sqrt().Sub(NewFloatRat(root, 256)).Div(NewFloatRat(root, 256))
+		if d.Abs().Cmp(NewFloatInt(NewInt(1), 256).Div(NewFloatInt(NewInt(1).Lsh(250), 256))) > 0 {
+			t.Errorf("sqrt(%v) is off by %v", testCase.x, d.Float64())
+		}
+	}
+}
+
 func TestFloatSqrtNeg(t *testing.T) {
 	x := NewFloat(-10.0)
 	defer func() {
@@ -272,3 +293,229 @@ func TestFloatPrint(t *testing.T) {
 		}
 	}
 }*/
+
+func TestFloatLog(t *testing.T) {
+	testCases := []struct {
+		x, log float64
+	}{
+		{1, 0}, {2, 0.6931471805599453}, {10, 2.302585092994046},
+		{0.3, -1.2039728043259361}, {12345.678, 9.421061321291832},
+	}
+	for _, testCase := range testCases {
+		got := NewFloat(testCase.x).SetPrecision(64).Log().Float64()
+		if got != testCase.log {
+			t.Errorf("log(%v) = %v, got %v", testCase.x, testCase.log, got)
+		}
+	}
+	// log(2^100 * 3) = 100 log 2 + log 3.
+	x := NewFloatInt(NewInt(3).Lsh(100), 128)
+	if got := x.Log().Float64(); got != 70.41333034466264 {
+		t.Errorf("log(3 * 2^100) = 70.41333034466264, got %v", got)
+	}
+	// log(10) to 256 bits.
+	ln10, _ := new(big.Rat).SetString("2.3025850929940456840179914546843642076011014886287729760333279009675726096773524802359972050895982983419677840422862486")
+	d := NewFloatInt(NewInt(10), 256).Log().Sub(NewFloatRat(ln10, 256))
+	if d.Abs().Cmp(NewFloatInt(NewInt(1), 256).Div(NewFloatInt(NewInt(1).Lsh(250), 256))) > 0 {
+		t.Errorf("log(10) is off by %v", d.Float64())
+	}
+}
+
+func TestFloatContinuedFraction(t *testing.T) {
+	pi := NewFloat(3.141592653589793)
+	expect := []int64{3, 7, 15, 1, 292, 1, 1, 1, 2, 1, 3, 1, 14}
+	terms := pi.ContinuedFraction().Terms()
+	if len(terms) < 5 || len(terms) > len(expect) {
+		t.Fatalf("expected 5 to %d terms, got %d", len(expect), len(terms))
+	}
+	for i, a := range terms {
+		if a.Int64() != expect[i] {
+			t.Errorf("term %d: expected %d, got %s", i, expect[i], a)
+		}
+	}
+
+	two := NewFloatInt(NewInt(2), 200)
+	terms = two.Sqrt().ContinuedFraction().Terms()
+	if len(terms) < 60 {
+		t.Errorf("expected at least 60 terms, got %d", len(terms))
+	}
+	for i, a := range terms {
+		if i > 0 && a.Int64() != 2 || i == 0 && a.Int64() != 1 {
+			t.Errorf("term %d of sqrt(2): got %s", i, a)
+		}
+	}
+}
+
+func TestFloatSpecialFunctions(t *testing.T) {
+	testCases := []struct {
+		name   string
+		x      *Float
+		expect float64
+	}{
+		{"pi", Pi(128), 3.141592653589793},
+		{"gamma", EulerGamma(128), 0.5772156649015329},
+		{"exp(1)", NewFloatInt(NewInt(1), 128).Exp(), 2.718281828459045},
+		{"exp(-10)", NewFloatInt(NewInt(-10), 128).Exp(), 4.5399929762484854e-05},
+		{"exp(100)", NewFloatInt(NewInt(100), 128).Exp(), 2.6881171418161356e+43},
+		{"erfc(0.5)", NewFloat(0.5).SetPrecision(128).Erfc(), 0.4795001221869535},
+		{"erfc(-1)", NewFloat(-1).SetPrecision(128).Erfc(), 1.8427007929497148},
+		{"erfc(6)", NewFloat(6).SetPrecision(128).Erfc(), 2.1519736712498913e-17},
+		{"E1(0.25)", NewFloat(0.25).SetPrecision(128).ExpIntegralE1(), 1.0442826344437380},
+		{"E1(10)", NewFloat(10).SetPrecision(128).ExpIntegralE1(), 4.156968929685324e-06},
+		{"cos(1)", NewFloat(1).SetPrecision(128).Cos(), 0.5403023058681398},
+		{"sin(1)", NewFloat(1).SetPrecision(128).Sin(), 0.8414709848078965},
+		{"cos(-2)", NewFloat(-2).SetPrecision(128).Cos(), -0.4161468365471424},
+		{"sin(-2)", NewFloat(-2).SetPrecision(128).Sin(), -0.9092974268256817},
+		{"sin(4)", NewFloat(4).SetPrecision(128).Sin(), -0.7568024953079282},
+		{"cos(100)", NewFloatInt(NewInt(100), 128).Cos(), 0.8623188722876839},
+	}
+	for _, c := range testCases {
+		f := c.x.Float64()
+		if math.Abs(f-c.expect) > 1e-14*math.Abs(c.expect) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expect, f)
+		}
+	}
+
+	// pi to 60 digits.
+	digits, _ := new(big.Int).SetString("3141592653589793238462643383279502884197169399375105820974944", 10)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(60), nil)
+	pi := Pi(256).Mul(NewFloatInt((*Int)(scale), 256)).Sub(NewFloatInt((*Int)(digits), 256))
+	if pi.Abs().Cmp(NewFloat(1)) > 0 {
+		t.Errorf("pi is wrong at 60 digits: %v", pi.Float64())
+	}
+}
+
+func TestFloatGamma(t *testing.T) {
+	f := func(s string) *Float {
+		r, _ := new(big.Rat).SetString(s)
+		return NewFloatRat(r, 128)
+	}
+	sqrtPi := "1.7724538509055160272981674833411451827975494561223871282138"
+	testCases := []struct {
+		name   string
+		x      *Float
+		expect string
+	}{
+		{"Gamma(1/2)", f("1/2").Gamma(), sqrtPi},
+		{"Gamma(5)", f("5").Gamma(), "24"},
+		{"Gamma(-1/2)", f("-1/2").Gamma(), "-3.5449077018110320545963349666822903655950989122447742564276"},
+		{"Gamma(1/4)", f("1/4").Gamma(), "3.6256099082219083119306851558676720029951676828800654674333"},
+		{"Gamma(-7/4)", f("-7/4").Gamma(), "2.7623694538833587138519505949467977165677468060038594037587"},
+		{"Gamma(1/4, 2)", UpperGamma(f("1/4"), f("2")), "0.0626723358715054272335234325904297732934840231685142418999"},
+		{"Gamma(1/4, 1/100)", UpperGamma(f("1/4"), f("1/100")), "2.3632216551848579357541334588410604107942284551323616561367"},
+		{"Gamma(-3/4, 2)", UpperGamma(f("-3/4"), f("2")), "0.0237313399952516290450124923573134325871616223529467092920"},
+		{"Gamma(-3/4, 1/100)", UpperGamma(f("-3/4"), f("1/100")), "38.593204082665684206391109479451600099949975609142807885587"},
+		{"Gamma(1/4, 30)", UpperGamma(f("1/4"), f("30")), "7.1273042552598226832686377520618856646885456779995E-15"},
+		// Gamma(1, x) = e^-x and Gamma(1/2, x) = sqrt(pi) erfc(sqrt(x)).
+		{"Gamma(1, 3)", UpperGamma(f("1"), f("3")), "0.0497870683678639429793424156500617766316995921884232155676"},
+		{"Gamma(1/2, 1/4)", UpperGamma(f("1/2"), f("1/4")), "0.8498918380799311297867616098602389766300312781938048348528"},
+		{"Gamma(0, 1/4)", UpperGamma(f("0"), f("1/4")), "1.0442826344437381945364381612322822518915283747448027186351"},
+		{"Gamma(-1, 1)", UpperGamma(f("-1"), f("1")), "0.1484955067759220479183599947013392184147638376248596269298"},
+	}
+	for _, c := range testCases {
+		r, _ := new(big.Rat).SetString(c.expect)
+		d := c.x.Sub(NewFloatRat(r, 128)).Abs()
+		if d.Cmp(NewFloatRat(big.NewRat(1, 1), 128).Div(f("1e40"))) > 0 {
+			t.Errorf("%s: expected %s, got %v", c.name, c.expect, c.x.Float64())
+		}
+	}
+}
+
+func TestFloatAtan(t *testing.T) {
+	f := func(x float64) *Float { return NewFloat(x).SetPrecision(128) }
+	testCases := []struct {
+		name   string
+		x      *Float
+		expect float64
+	}{
+		{"atan(0.5)", f(0.5).Atan(), 0.4636476090008061},
+		{"atan(-3)", f(-3).Atan(), -1.2490457723982544},
+		{"atan2(1, -1)", Atan2(f(1), f(-1)), 2.356194490192345},
+		{"atan2(-1, -1)", Atan2(f(-1), f(-1)), -2.356194490192345},
+		{"atan2(0, -1)", Atan2(f(0), f(-1)), math.Pi},
+		{"atan2(-2, 0.5)", Atan2(f(-2), f(0.5)), -1.3258176636680326},
+		{"atan2(3, 0)", Atan2(f(3), f(0)), math.Pi / 2},
+	}
+	for _, c := range testCases {
+		if x := c.x.Float64(); math.Abs(x-c.expect) > 1e-15 {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expect, x)
+		}
+	}
+}
+
+func TestComplex(t *testing.T) {
+	c := func(s string) *Complex {
+		z, err := ParseComplex(s, 128)
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+		return z
+	}
+	testCases := []struct {
+		name   string
+		z      *Complex
+		expect complex128
+	}{
+		{"mul", c("1+2i").Mul(c("3-i")), 5 + 5i},
+		{"div", c("5+5i").Div(c("3-i")), 1 + 2i},
+		{"sub", c("1/2").Sub(c("i")), 0.5 - 1i},
+		{"conj", c("(2-3i)").Conj(), 2 + 3i},
+		{"sqrt", c("3+4i").Sqrt(), 2 + 1i},
+		{"sqrt", c("-3+4i").Sqrt(), 1 + 2i},
+		{"sqrt", c("-3-4i").Sqrt(), 1 - 2i},
+		{"sqrt", c("-4").Sqrt(), 2i},
+		{"exp", c("3.14159265358979323846264338327950288i").Exp(), -1},
+		{"log", c("-1").Log(), math.Pi * 1i},
+		{"log", c("1+i").Log(), complex(math.Log(2)/2, math.Pi/4)},
+		{"pow", c("i").Pow(c("i")), complex(math.Exp(-math.Pi/2), 0)},
+		{"pow", c("1+i").Pow(c("2")), 2i},
+		{"pow", c("0").Pow(c("2.5")), 0},
+	}
+	for _, tc := range testCases {
+		got := complex(tc.z.Real().Float64(), tc.z.Imag().Float64())
+		if d := got - tc.expect; math.Hypot(real(d), imag(d)) > 1e-15 {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expect, got)
+		}
+	}
+	z := c("3+4i")
+	if r := z.Abs().Float64(); r != 5 {
+		t.Errorf("|3+4i| = %v", r)
+	}
+	if a := z.Arg().Float64(); math.Abs(a-0.9272952180016122) > 1e-15 {
+		t.Errorf("arg(3+4i) = %v", a)
+	}
+
+	// exp(log z) = z to full precision.
+	z, _ = ParseComplex("-0.75+1.25i", 256)
+	d := z.Log().Exp().Sub(z)
+	if d.Abs().Cmp(NewFloatInt(NewInt(1), 256).Div(NewFloatInt(NewInt(1).Lsh(240), 256))) > 0 {
+		t.Errorf("exp(log z) - z = %v", d)
+	}
+}
+
+func TestComplexParse(t *testing.T) {
+	testCases := []struct {
+		s, expect string
+	}{
+		{"(1.5-2.25i)", "(1.5-2.25i)"},
+		{"i", "(0+1i)"},
+		{"-i", "(0-1i)"},
+		{"3", "(3+0i)"},
+		{" 2e-3+1e+2i\n", "(0.002+100i)"},
+		{"-1/4+1/8i", "(-0.25+0.125i)"},
+		{"1/3", "(0.333333333333333+0i)"},
+	}
+	for _, c := range testCases {
+		z, err := ParseComplex(c.s, 52)
+		if err != nil {
+			t.Fatalf("%s: %v", c.s, err)
+		}
+		if z.String() != c.expect {
+			t.Errorf("%s: expected %s, got %s", c.s, c.expect, z)
+		}
+	}
+	for _, s := range []string{"", "abc", "1+2j", "1+-2i", "(1+i", "3 4i", "1 2", "1 + 2i", "( 1+2i)"} {
+		if _, err := ParseComplex(s, 52); err != ErrComplexSyntax {
+			t.Errorf("%q: expected ErrComplexSyntax, got %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package float
+
+import (
+	"math"
+	"math/big"
+	. "mathx"
+)
+
+// Compute the gamma function of x, which must not be a non-positive
+// integer, from Gamma(b) for the fractional part b of x and
+// Gamma(c + 1) = c Gamma(c).
+func (x *Float) Gamma() *Float {
+	n, b := splitRat(x.Rat())
+	if b.Sign() == 0 && n <= 0 {
+		panic("gamma function at a non-positive integer is undefined\n")
+	}
+	wp := x.workingPrecision()
+	w := wp + guardBits
+	c := new(big.Rat).Set(b)
+	var G *big.Int
+	switch {
+	case b.Sign() == 0:
+		G = new(big.Int).Lsh(big.NewInt(1), w)
+		c.SetInt64(1)
+		n--
+	case b.Cmp(big.NewRat(1, 2)) == 0:
+		G = sqrtPiFixed(w)
+	default:
+		G = gammaFixed(ratFixed(b, w), w)
+	}
+	for ; n > 0; n-- {
+		G.Mul(G, ratFixed(c, w)).Rsh(G, w)
+		c.Add(c, big.NewRat(1, 1))
+	}
+	for ; n < 0; n++ {
+		c.Sub(c, big.NewRat(1, 1))
+		G.Lsh(G, w).Quo(G, ratFixed(c, w))
+	}
+	return fromFixed(G, w, x.precision)
+}
+
+// Compute the upper incomplete gamma function
+// Gamma(a, x) = int_x^oo e^(-t) t^(a - 1) dt for x > 0 and any a. For the
+// fractional part b of a, Gamma(b, x) is E1(x) for b = 0,
+// sqrt(pi) erfc(sqrt(x)) for b = 1/2 and Gamma(b) - gamma(b, x)
+// otherwise, and the recurrence
+// Gamma(c + 1, x) = c Gamma(c, x) + x^c e^(-x) reaches a from b.
+func UpperGamma(a, x *Float) *Float {
+	if x.mantissa.Sign() == 0 || !x.sign {
+		panic("incomplete gamma function at a non-positive number is undefined\n")
+	}
+	precision := x.precision
+	if a.precision < precision {
+		precision = a.precision
+	}
+	wp := uint(2*precision) + guardBits
+	n, b := splitRat(a.Rat())
+	af, xf := a.Float64(), x.Float64()
+	bf, _ := b.Float64()
+	// Gamma(a, x) is about x^(a - 1) e^-x for large x.
+	if xf > 1 && ((af-1)*math.Log(xf)-xf)/math.Ln2 < -float64(wp)-8 {
+		return NewFloatInt(NewInt(0), precision)
+	}
+	// The recurrence adds x^c e^-x, which must keep wp bits.
+	w := wp + guardBits
+	if xf > 1 {
+		w += uint((xf - bf*math.Log(xf)) / math.Ln2)
+	}
+	X := x.fixed(w)
+	LX := x.logFixed(w)
+	var G *big.Int
+	switch {
+	case b.Sign() == 0 && X.Sign() == 0:
+		// E1(x) = -gamma - log x + O(x).
+		G = eulerGammaFixed(w)
+		G.Neg(G).Sub(G, LX)
+	case b.Sign() == 0:
+		G = expIntegralE1Fixed(X, w)
+	case b.Cmp(big.NewRat(1, 2)) == 0:
+		G = erfcFixed(new(big.Int).Sqrt(new(big.Int).Lsh(X, w)), w)
+		G.Mul(G, sqrtPiFixed(w)).Rsh(G, w)
+	default:
+		B := ratFixed(b, w)
+		G = gammaFixed(B, w)
+		G.Sub(G, lowerGammaFixed(B, X, LX, w))
+	}
+	c := new(big.Rat).Set(b)
+	for ; n > 0; n-- {
+		G.Mul(G, ratFixed(c, w)).Rsh(G, w)
+		G.Add(G, powExpFixed(ratFixed(c, w), X, LX, w))
+		c.Add(c, big.NewRat(1, 1))
+	}
+	for ; n < 0; n++ {
+		// Gamma(c - 1, x) = (Gamma(c, x) - x^(c - 1) e^-x) / (c - 1).
+		c.Sub(c, big.NewRat(1, 1))
+		C := ratFixed(c, w)
+		G.Sub(G, powExpFixed(C, X, LX, w))
+		G.Lsh(G, w).Quo(G, C)
+	}
+	return fromFixed(G, w, precision)
+}
+
+// Split r into floor(r) and the fractional part r - floor(r) in [0, 1).
+func splitRat(r *big.Rat) (int64, *big.Rat) {
+	n := new(big.Int).Div(r.Num(), r.Denom())
+	return n.Int64(), new(big.Rat).Sub(r, new(big.Rat).SetInt(n))
+}
+
+// Return floor(r 2^wp).
+func ratFixed(r *big.Rat, wp uint) *big.Int {
+	X := new(big.Int).Lsh(r.Num(), wp)
+	return X.Div(X, r.Denom())
+}
+
+func sqrtPiFixed(wp uint) *big.Int {
+	return new(big.Int).Sqrt(new(big.Int).Lsh(piFixed(wp), wp))
+}
+
+// Return x^c e^-x in fixed point, given x and log x.
+func powExpFixed(C, X, LX *big.Int, wp uint) *big.Int {
+	E := new(big.Int).Mul(C, LX)
+	E.Rsh(E, wp).Sub(E, X)
+	return expFixedAbs(E, wp)
+}
+
+// Compute Gamma(b) for 0 < b < 1 as gamma(b, x) for x with
+// Gamma(b, x) < e^-x below 2^-wp.
+func gammaFixed(B *big.Int, wp uint) *big.Int {
+	x := int64(float64(wp+guardBits)*math.Ln2) + 8
+	X := new(big.Int).Lsh(big.NewInt(x), wp)
+	return lowerGammaFixed(B, X, lnFixed(X, wp), wp)
+}
+
+// Compute the lower incomplete gamma function
+// gamma(b, x) = x^b e^-x sum x^n / (b (b + 1) ... (b + n)) for b > 0,
+// given x and log x. The terms grow to about e^x, so x / log 2 more bits
+// are carried.
+// Abramowitz and Stegun, 6.5.29.
+func lowerGammaFixed(B, X, LX *big.Int, wp uint) *big.Int {
+	extra := uint(fixedFloat64(X, wp)/math.Ln2) + guardBits
+	w := wp + extra
+	one := new(big.Int).Lsh(big.NewInt(1), w)
+	Bw := new(big.Int).Lsh(B, extra)
+	Xw := new(big.Int).Lsh(X, extra)
+	term := new(big.Int).Lsh(one, w)
+	term.Quo(term, Bw)
+	sum := new(big.Int).Set(term)
+	d := new(big.Int)
+	for k := int64(1); term.Sign() != 0; k++ {
+		term.Mul(term, Xw).Rsh(term, w)
+		d.Mul(one, big.NewInt(k)).Add(d, Bw)
+		term.Lsh(term, w).Quo(term, d)
+		sum.Add(sum, term)
+	}
+	sum.Mul(sum, powExpFixed(Bw, Xw, new(big.Int).Lsh(LX, extra), w))
+	return sum.Rsh(sum, w+extra)
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package float
+
+import (
+	"math/big"
+	. "mathx"
+)
+
+// Convert an integer to a float of the given precision.
+func NewFloatInt(x *Int, precision uint64) *Float {
+	z := new(Float)
+	z.precision = precision
+	z.sign = x.Sign() >= 0
+	z.mantissa = x.Copy()
+	if !z.sign {
+		z.mantissa = NewInt(0).Sub(x)
+	}
+	return z.normalize()
+}
+
+// Convert a rational number to a float of the given precision.
+func NewFloatRat(x *big.Rat, precision uint64) *Float {
+	z := NewFloatInt((*Int)(new(big.Int).Set(x.Num())), precision)
+	if x.IsInt() {
+		return z
+	}
+	return z.Div(NewFloatInt((*Int)(new(big.Int).Set(x.Denom())), precision))
+}
+
+func (x *Float) Precision() uint64 {
+	return x.precision
+}
+
+// Return a copy of x with the given precision.
+func (x *Float) SetPrecision(precision uint64) *Float {
+	z := x.Copy()
+	z.precision = precision
+	return z.normalize()
+}
+
+// Return the float64 closest to x.
+func (x *Float) Float64() float64 {
+	if x.mantissa.Sign() == 0 {
+		return 0
+	}
+	m := new(big.Float).SetInt((*big.Int)(x.mantissa))
+	f, _ := m.SetMantExp(m, int(x.exp)).Float64()
+	if !x.sign {
+		return -f
+	}
+	return f
+}
+
+// Compute the natural logarithm of x > 0. Writing x = m 2^e with
+// 1 <= m < 2, log x = log m + e log 2, with log m from lnFixed.
+func (x *Float) Log() *Float {
+	if x.mantissa.Sign() == 0 || !x.sign {
+		panic("logarithm of a non-positive number is undefined\n")
+	}
+	wp := x.workingPrecision()
+	return fromFixed(x.logFixed(wp), wp, x.precision)
+}
+
+// Return log x 2^wp for x > 0, which unlike lnFixed(x.fixed(wp), wp)
+// keeps its precision for tiny and huge x.
+func (x *Float) logFixed(wp uint) *big.Int {
+	m := new(big.Int).Set((*big.Int)(x.mantissa))
+	n := int64(m.BitLen())
+	if shift := int64(wp) + 1 - n; shift >= 0 {
+		m.Lsh(m, uint(shift))
+	} else {
+		m.Rsh(m, uint(-shift))
+	}
+	z := lnFixed(m, wp)
+	if e := x.exp + n - 1; e != 0 {
+		ln2 := ln2Fixed(wp + guardBits)
+		ln2.Mul(ln2, big.NewInt(e))
+		z.Add(z, ln2.Rsh(ln2, guardBits))
+	}
+	return z
+}
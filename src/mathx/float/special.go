@@ -0,0 +1,405 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package float
+
+import (
+	"math"
+	"math/big"
+	. "mathx"
+)
+
+// The constants and special functions below work in fixed point, on
+// integers X standing for X / 2^wp, with wp = 2 precision + guard bits,
+// and round to a Float at the end.
+const guardBits = 32
+
+func (x *Float) workingPrecision() uint {
+	return uint(2*x.precision) + guardBits
+}
+
+// Return floor(x 2^wp).
+func (x *Float) fixed(wp uint) *big.Int {
+	X := new(big.Int).Set((*big.Int)(x.mantissa))
+	if shift := x.exp + int64(wp); shift >= 0 {
+		X.Lsh(X, uint(shift))
+	} else {
+		X.Rsh(X, uint(-shift))
+	}
+	if !x.sign {
+		X.Neg(X)
+	}
+	return X
+}
+
+// Return X 2^-wp as a Float of the given precision.
+func fromFixed(X *big.Int, wp uint, precision uint64) *Float {
+	return fromMantExp(X, -int64(wp), precision)
+}
+
+// Return X 2^e as a Float of the given precision.
+func fromMantExp(X *big.Int, e int64, precision uint64) *Float {
+	z := NewFloat(0)
+	z.precision = precision
+	if X.Sign() == 0 {
+		return z
+	}
+	z.sign = X.Sign() > 0
+	z.exp = e
+	z.mantissa = (*Int)(new(big.Int).Abs(X))
+	return z.normalize()
+}
+
+// Return an approximation of x 2^-wp for estimating sizes.
+func fixedFloat64(X *big.Int, wp uint) float64 {
+	f, _ := new(big.Float).SetMantExp(new(big.Float).SetInt(X), -int(wp)).Float64()
+	return f
+}
+
+// Compute pi to the given precision by Machin's formula
+// pi = 16 atan(1/5) - 4 atan(1/239).
+func Pi(precision uint64) *Float {
+	wp := uint(2*precision) + guardBits
+	return fromFixed(piFixed(wp), wp, precision)
+}
+
+func piFixed(wp uint) *big.Int {
+	w := wp + guardBits
+	p := arctanInvFixed(5, w, false)
+	q := arctanInvFixed(239, w, false)
+	p.Lsh(p, 4).Sub(p, q.Lsh(q, 2))
+	return p.Rsh(p, guardBits)
+}
+
+// Compute atan(1/m), or atanh(1/m) if hyperbolic, as the sum of
+// (-1)^k / ((2k + 1) m^(2k + 1)), without the signs for atanh.
+func arctanInvFixed(m int64, wp uint, hyperbolic bool) *big.Int {
+	term := new(big.Int).Lsh(big.NewInt(1), wp)
+	bm := big.NewInt(m)
+	term.Quo(term, bm)
+	m2 := big.NewInt(m * m)
+	sum := new(big.Int).Set(term)
+	t := new(big.Int)
+	for k := int64(1); term.Sign() != 0; k++ {
+		term.Quo(term, m2)
+		t.Quo(term, big.NewInt(2*k+1))
+		if k&1 == 1 && !hyperbolic {
+			sum.Sub(sum, t)
+		} else {
+			sum.Add(sum, t)
+		}
+	}
+	return sum
+}
+
+// Compute log 2 = 2 atanh(1/3).
+func ln2Fixed(wp uint) *big.Int {
+	return arctanInvFixed(3, wp+1, true)
+}
+
+// Compute log x for x > 0, writing x = m 2^e with 1 <= m < 2 and
+// log m = 2 atanh((m - 1) / (m + 1)).
+func lnFixed(X *big.Int, wp uint) *big.Int {
+	w := wp + guardBits
+	e := int64(X.BitLen()) - 1 - int64(wp)
+	m := new(big.Int).Set(X)
+	if shift := int64(guardBits) - e; shift >= 0 {
+		m.Lsh(m, uint(shift))
+	} else {
+		m.Rsh(m, uint(-shift))
+	}
+	one := new(big.Int).Lsh(big.NewInt(1), w)
+	y := new(big.Int).Sub(m, one)
+	y.Lsh(y, w).Quo(y, m.Add(m, one))
+	y2 := new(big.Int).Mul(y, y)
+	y2.Rsh(y2, w)
+	sum := new(big.Int).Set(y)
+	term := new(big.Int).Set(y)
+	t := new(big.Int)
+	for k := int64(1); term.Sign() != 0; k++ {
+		term.Mul(term, y2).Rsh(term, w)
+		sum.Add(sum, t.Quo(term, big.NewInt(2*k+1)))
+	}
+	sum.Lsh(sum, 1)
+	sum.Add(sum, t.Mul(ln2Fixed(w), big.NewInt(e)))
+	return sum.Rsh(sum, guardBits)
+}
+
+// Compute e^x = 2^k e^r with r = x - k log 2, |r| <= log 2, returning the
+// fixed point e^r and k.
+func expFixed(X *big.Int, wp uint) (*big.Int, int64) {
+	w := wp + guardBits
+	ln2 := ln2Fixed(w)
+	r := new(big.Int).Lsh(X, guardBits)
+	k := new(big.Int)
+	k.Quo(r, ln2)
+	r.Sub(r, new(big.Int).Mul(k, ln2))
+	sum := new(big.Int).Lsh(big.NewInt(1), w)
+	term := new(big.Int).Set(sum)
+	for i := int64(1); term.Sign() != 0; i++ {
+		term.Mul(term, r).Rsh(term, w)
+		term.Quo(term, big.NewInt(i))
+		sum.Add(sum, term)
+	}
+	return sum.Rsh(sum, guardBits), k.Int64()
+}
+
+// Compute e^x.
+func (x *Float) Exp() *Float {
+	wp := x.workingPrecision()
+	m, k := expFixed(x.fixed(wp), wp)
+	return fromMantExp(m, k-int64(wp), x.precision)
+}
+
+// Compute cos x.
+func (x *Float) Cos() *Float {
+	wp := x.workingPrecision()
+	c, _ := sinCosFixed(x.fixed(wp), wp)
+	return fromFixed(c, wp, x.precision)
+}
+
+// Compute sin x.
+func (x *Float) Sin() *Float {
+	wp := x.workingPrecision()
+	_, s := sinCosFixed(x.fixed(wp), wp)
+	return fromFixed(s, wp, x.precision)
+}
+
+// Compute cos x and sin x from x = k pi / 2 + r with |r| <= pi / 4, by
+// the Taylor series of cos r and sin r rotated by k quarter turns.
+func sinCosFixed(X *big.Int, wp uint) (*big.Int, *big.Int) {
+	w := wp + guardBits
+	halfPi := piFixed(w)
+	halfPi.Rsh(halfPi, 1)
+	r := new(big.Int).Lsh(X, guardBits)
+	// k = floor((2 r + pi / 2) / pi), the nearest multiple of pi / 2.
+	k := new(big.Int).Lsh(r, 1)
+	k.Add(k, halfPi).Div(k, new(big.Int).Lsh(halfPi, 1))
+	r.Sub(r, new(big.Int).Mul(k, halfPi))
+	r2 := new(big.Int).Mul(r, r)
+	r2.Rsh(r2, w)
+	c := new(big.Int).Lsh(big.NewInt(1), w)
+	s := new(big.Int).Set(r)
+	ct, st := new(big.Int).Set(c), new(big.Int).Set(s)
+	for i := int64(1); ct.Sign() != 0 || st.Sign() != 0; i++ {
+		ct.Mul(ct, r2).Rsh(ct, w).Quo(ct, big.NewInt(-(2*i-1)*2*i))
+		st.Mul(st, r2).Rsh(st, w).Quo(st, big.NewInt(-2*i*(2*i+1)))
+		c.Add(c, ct)
+		s.Add(s, st)
+	}
+	switch new(big.Int).And(k, big.NewInt(3)).Int64() {
+	case 1:
+		c, s = s.Neg(s), c
+	case 2:
+		c, s = c.Neg(c), s.Neg(s)
+	case 3:
+		c, s = s, c.Neg(c)
+	}
+	return c.Rsh(c, guardBits), s.Rsh(s, guardBits)
+}
+
+// Compute atan x, using atan x = +-pi / 2 - atan(1 / x) for |x| > 1.
+func (x *Float) Atan() *Float {
+	wp := x.workingPrecision()
+	one := NewFloatInt(NewInt(1), x.precision)
+	if x.Abs().Cmp(one) <= 0 {
+		return fromFixed(atanFixed(x.fixed(wp), wp), wp, x.precision)
+	}
+	A := atanFixed(one.Div(x).fixed(wp), wp)
+	halfPi := piFixed(wp)
+	halfPi.Rsh(halfPi, 1)
+	if x.sign {
+		A.Sub(halfPi, A)
+	} else {
+		A.Neg(A).Sub(A, halfPi)
+	}
+	return fromFixed(A, wp, x.precision)
+}
+
+// Compute the angle in (-pi, pi] of the point (x, y), as atan(y / x) or
+// +-pi / 2 - atan(x / y), whichever ratio is at most 1 in absolute value,
+// corrected by pi for x < 0. Atan2(0, 0) is 0.
+func Atan2(y, x *Float) *Float {
+	precision := x.precision
+	if y.precision < precision {
+		precision = y.precision
+	}
+	if y.mantissa.Sign() == 0 && x.mantissa.Sign() == 0 {
+		return NewFloatInt(NewInt(0), precision)
+	}
+	wp := uint(2*precision) + guardBits
+	pi := piFixed(wp)
+	var A *big.Int
+	if y.Abs().Cmp(x.Abs()) <= 0 {
+		A = atanFixed(y.Div(x).fixed(wp), wp)
+		if !x.sign && (y.sign || y.mantissa.Sign() == 0) {
+			A.Add(A, pi)
+		} else if !x.sign {
+			A.Sub(A, pi)
+		}
+	} else {
+		A = atanFixed(x.Div(y).fixed(wp), wp)
+		halfPi := pi.Rsh(pi, 1)
+		if y.sign {
+			A.Sub(halfPi, A)
+		} else {
+			A.Neg(A).Sub(A, halfPi)
+		}
+	}
+	return fromFixed(A, wp, precision)
+}
+
+// Compute atan x for |x| <= 1 by halving the angle with
+// atan x = 2 atan(x / (1 + sqrt(1 + x^2))) a few times and summing the
+// Taylor series (-1)^k x^(2k + 1) / (2k + 1).
+func atanFixed(X *big.Int, wp uint) *big.Int {
+	const halvings = 8
+	w := wp + guardBits
+	one := new(big.Int).Lsh(big.NewInt(1), w)
+	x := new(big.Int).Lsh(X, guardBits)
+	s := new(big.Int)
+	for i := 0; i < halvings; i++ {
+		s.Mul(x, x).Add(s, new(big.Int).Lsh(one, w)).Sqrt(s)
+		x.Lsh(x, w).Quo(x, s.Add(s, one))
+	}
+	x2 := new(big.Int).Mul(x, x)
+	x2.Rsh(x2, w)
+	sum := new(big.Int).Set(x)
+	term := new(big.Int).Set(x)
+	t := new(big.Int)
+	for k := int64(1); term.Sign() != 0; k++ {
+		term.Mul(term, x2).Rsh(term, w).Neg(term)
+		sum.Add(sum, t.Quo(term, big.NewInt(2*k+1)))
+	}
+	sum.Lsh(sum, halvings)
+	return sum.Rsh(sum, guardBits)
+}
+
+// Return e^x 2^-wp in fixed point, which underflows to 0 for x << 0.
+func expFixedAbs(X *big.Int, wp uint) *big.Int {
+	m, k := expFixed(X, wp)
+	if k >= 0 {
+		return m.Lsh(m, uint(k))
+	}
+	return m.Rsh(m, uint(-k))
+}
+
+// Compute Euler's constant by the Brent-McMillan formula
+// gamma = U / V - log n + O(e^(-4n)), with U and V the sums of
+// A_k = (A_{k-1} n^2 / k + B_k) / k, A_0 = -log n, and
+// B_k = B_{k-1} n^2 / k^2, B_0 = 1.
+func EulerGamma(precision uint64) *Float {
+	wp := uint(2*precision) + guardBits
+	return fromFixed(eulerGammaFixed(wp), wp, precision)
+}
+
+func eulerGammaFixed(wp uint) *big.Int {
+	w := wp + guardBits
+	n := int64(float64(w)*math.Ln2/4) + 1
+	n2 := big.NewInt(n * n)
+	A := lnFixed(new(big.Int).Lsh(big.NewInt(n), w), w)
+	A.Neg(A)
+	B := new(big.Int).Lsh(big.NewInt(1), w)
+	U, V := new(big.Int).Set(A), new(big.Int).Set(B)
+	for k := int64(1); A.Sign() != 0 || B.Sign() != 0; k++ {
+		bk := big.NewInt(k)
+		B.Mul(B, n2).Quo(B, bk).Quo(B, bk)
+		A.Mul(A, n2).Quo(A, bk).Add(A, B).Quo(A, bk)
+		U.Add(U, A)
+		V.Add(V, B)
+	}
+	U.Lsh(U, w).Quo(U, V)
+	return U.Rsh(U, guardBits)
+}
+
+// Compute the complementary error function
+// erfc(x) = 2 / sqrt(pi) int_x^oo e^(-t^2) dt, from
+// erf(x) = 2 / sqrt(pi) e^(-x^2) sum 2^k x^(2k + 1) / (1 3 ... (2k + 1))
+// for x >= 0 and erfc(-x) = 2 - erfc(x). The terms grow to about e^(x^2),
+// so x^2 / log 2 more bits are carried.
+func (x *Float) Erfc() *Float {
+	wp := x.workingPrecision()
+	X := x.fixed(wp)
+	negative := X.Sign() < 0
+	Y := erfcFixed(X.Abs(X), wp)
+	if negative {
+		Y.Sub(new(big.Int).Lsh(big.NewInt(1), wp+1), Y)
+	}
+	return fromFixed(Y, wp, x.precision)
+}
+
+func erfcFixed(X *big.Int, wp uint) *big.Int {
+	xf := fixedFloat64(X, wp)
+	x2f := xf * xf
+	if x2f > float64(wp)*math.Ln2+8 {
+		return new(big.Int)
+	}
+	extra := uint(x2f/math.Ln2) + guardBits
+	w := wp + extra
+	Xw := new(big.Int).Lsh(X, extra)
+	x2 := new(big.Int).Mul(Xw, Xw)
+	x2.Rsh(x2, w)
+	term := new(big.Int).Set(Xw)
+	sum := new(big.Int).Set(Xw)
+	for k := int64(1); term.Sign() != 0; k++ {
+		term.Mul(term, x2).Rsh(term, w-1)
+		term.Quo(term, big.NewInt(2*k+1))
+		sum.Add(sum, term)
+	}
+	e := expFixedAbs(new(big.Int).Neg(x2), w)
+	sum.Mul(sum, e).Rsh(sum, w-1)
+	sqrtPi := new(big.Int).Sqrt(new(big.Int).Lsh(piFixed(w), w))
+	sum.Lsh(sum, w).Quo(sum, sqrtPi)
+	one := new(big.Int).Lsh(big.NewInt(1), w)
+	one.Sub(one, sum)
+	return one.Rsh(one, extra)
+}
+
+// Compute the exponential integral E1(x) = int_x^oo e^(-t) / t dt for
+// x > 0, from E1(x) = -gamma - log x - sum (-x)^k / (k k!). The terms grow
+// to about e^x, so x / log 2 more bits are carried.
+func (x *Float) ExpIntegralE1() *Float {
+	if x.mantissa.Sign() == 0 || !x.sign {
+		panic("exponential integral of a non-positive number is undefined\n")
+	}
+	wp := x.workingPrecision()
+	return fromFixed(expIntegralE1Fixed(x.fixed(wp), wp), wp, x.precision)
+}
+
+func expIntegralE1Fixed(X *big.Int, wp uint) *big.Int {
+	xf := fixedFloat64(X, wp)
+	if xf > float64(wp)*math.Ln2+8 {
+		return new(big.Int)
+	}
+	extra := uint(xf/math.Ln2) + guardBits
+	w := wp + extra
+	Xw := new(big.Int).Lsh(X, extra)
+	term := new(big.Int).Set(Xw)
+	sum := new(big.Int).Set(Xw)
+	t := new(big.Int)
+	for k := int64(2); term.Sign() != 0; k++ {
+		term.Mul(term, Xw).Rsh(term, w)
+		term.Quo(term, big.NewInt(k))
+		t.Quo(term, big.NewInt(k))
+		if k&1 == 0 {
+			sum.Sub(sum, t)
+		} else {
+			sum.Add(sum, t)
+		}
+	}
+	sum.Sub(sum, eulerGammaFixed(w))
+	sum.Sub(sum, lnFixed(Xw, w))
+	return sum.Rsh(sum, extra)
+}
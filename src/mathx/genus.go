@@ -0,0 +1,175 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import "math/big"
+
+// The genus theory of a fundamental discriminant D != 1: D is the
+// product of the prime discriminants -4, 8, -8 and p* = (-1)^((p-1)/2) p
+// for the primes p dividing D, and the genus of a primitive form of
+// discriminant D is given by the values (p* / n) of the corresponding
+// characters at any n it represents with gcd(n, D) = 1. The product of
+// the t values is 1, so there are 2^(t-1) genera, each with the same
+// number of classes, and the 2-rank of the (narrow) class group is t - 1.
+// Cox, Sec. 3.B and Thm. 6.1; Cohen, Sec. 5.6.
+type GenusStructure struct {
+	d          *big.Int
+	characters []*big.Int
+}
+
+// Compute the genus characters of the fundamental discriminant D.
+func Genera(D *big.Int) (*GenusStructure, error) {
+	if D.Cmp(intOne) == 0 || !IsFundamentalDiscriminant(D) {
+		return nil, ErrNotFundamentalDiscriminant
+	}
+	factors, err := factorBig(D)
+	if err != nil {
+		return nil, err
+	}
+	g := &GenusStructure{d: new(big.Int).Set(D)}
+	rest := new(big.Int).Set(D)
+	for _, f := range factors {
+		if f.prime.Cmp(big.NewInt(2)) == 0 {
+			continue
+		}
+		p := new(big.Int).Set(f.prime)
+		if p.Bit(1) == 1 {
+			p.Neg(p)
+		}
+		g.characters = append(g.characters, p)
+		rest.Quo(rest, p)
+	}
+	// What is left is 1 or the prime discriminant -4, 8 or -8 at 2.
+	if rest.Cmp(intOne) != 0 {
+		g.characters = append([]*big.Int{rest}, g.characters...)
+	}
+	return g, nil
+}
+
+func (g *GenusStructure) Discriminant() *big.Int {
+	return new(big.Int).Set(g.d)
+}
+
+// Return the prime discriminants whose product is D, in order of their
+// primes.
+func (g *GenusStructure) Characters() []*big.Int {
+	chars := make([]*big.Int, len(g.characters))
+	for i, p := range g.characters {
+		chars[i] = new(big.Int).Set(p)
+	}
+	return chars
+}
+
+// Return t - 1, for the t primes dividing D: the 2-rank of the class
+// group for D < 0, and of the narrow class group for D > 0.
+func (g *GenusStructure) TwoRank() int {
+	return len(g.characters) - 1
+}
+
+// Return the number 2^(t-1) of genera.
+func (g *GenusStructure) Count() int {
+	return 1 << uint(g.TwoRank())
+}
+
+// Return the values of the genus characters on the primitive form f of
+// discriminant D, or nil for any other form.
+func (g *GenusStructure) Characteristic(f *QuadraticForm) []int {
+	if !f.IsPrimitive() || f.Discriminant().Cmp(g.d) != 0 {
+		return nil
+	}
+	n := f.coprimeValue(g.d)
+	values := make([]int, len(g.characters))
+	for i, p := range g.characters {
+		values[i] = Kronecker(p, n)
+	}
+	return values
+}
+
+// Return the genus of the primitive form f of discriminant D, as a
+// number below Count() whose bit i is set when the i-th character is
+// -1, the last character being determined by the others; the principal
+// genus is 0. Returns -1 for any other form.
+func (g *GenusStructure) Genus(f *QuadraticForm) int {
+	values := g.Characteristic(f)
+	if values == nil {
+		return -1
+	}
+	genus := 0
+	for i, v := range values[:len(values)-1] {
+		if v < 0 {
+			genus |= 1 << uint(i)
+		}
+	}
+	return genus
+}
+
+// Return the reduced primitive forms of discriminant D grouped by genus,
+// indexed as by Genus. For D < 0 these are the h(D) reduced forms, one
+// per class; for D > 0 every form of each cycle of reduced forms is
+// listed. Returns ErrClassGroupTooLarge if |D| is at least
+// classNumberBSGSBound.
+func (g *GenusStructure) ReducedForms() ([][]*QuadraticForm, error) {
+	forms, err := ReducedForms(g.d)
+	if err != nil {
+		return nil, err
+	}
+	genera := make([][]*QuadraticForm, g.Count())
+	for _, f := range forms {
+		i := g.Genus(f)
+		genera[i] = append(genera[i], f)
+	}
+	return genera, nil
+}
+
+// List the reduced primitive forms of discriminant D, in the sense of
+// IsReduced: for D < 0 the positive definite ones, one per class, and
+// for D > 0 those with |sqrt(D) - 2|a|| < b < sqrt(D). Returns
+// ErrNotDiscriminant unless D is a discriminant that is not a square,
+// and ErrClassGroupTooLarge if |D| is at least classNumberBSGSBound.
+func ReducedForms(D *big.Int) ([]*QuadraticForm, error) {
+	if new(big.Int).Mod(D, big.NewInt(4)).Int64() > 1 || IsSquare(D) {
+		return nil, ErrNotDiscriminant
+	}
+	if D.CmpAbs(big.NewInt(classNumberBSGSBound)) >= 0 {
+		return nil, ErrClassGroupTooLarge
+	}
+	forms := []*QuadraticForm{}
+	eachReducedForm(D.Int64(), func(a, b, c int64) {
+		forms = append(forms, NewQuadraticForm64(a, b, c))
+	})
+	return forms, nil
+}
+
+// Return a value n = f(x, y) != 0 of the primitive form f with
+// gcd(n, m) = 1, trying coprime x, y in growing boxes; such values
+// exist by Cox, Lemma 2.25.
+func (f *QuadraticForm) coprimeValue(m *big.Int) *big.Int {
+	am := new(big.Int).Abs(m)
+	g := new(big.Int)
+	for r := int64(1); ; r++ {
+		for x := -r; x <= r; x++ {
+			for y := int64(0); y <= r; y++ {
+				if x != -r && x != r && y != r || gcd64(x*x, y) != 1 {
+					continue
+				}
+				n := f.Eval(big.NewInt(x), big.NewInt(y))
+				if n.Sign() != 0 && g.GCD(nil, nil, new(big.Int).Abs(n), am).Cmp(intOne) == 0 {
+					return n
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestGeneraCharacters(t *testing.T) {
+	testCases := []struct {
+		D          int64
+		characters []int64
+	}{
+		{-3, []int64{-3}}, {-4, []int64{-4}}, {5, []int64{5}}, {8, []int64{8}},
+		{-84, []int64{-4, -3, -7}}, {-20, []int64{-4, 5}}, {40, []int64{8, 5}},
+		{-24, []int64{8, -3}}, {24, []int64{-8, -3}}, {12, []int64{-4, -3}},
+		{-1155, []int64{-3, 5, -7, -11}},
+	}
+	for _, c := range testCases {
+		g, err := Genera(big.NewInt(c.D))
+		if err != nil {
+			t.Fatalf("%d: %v", c.D, err)
+		}
+		chars := g.Characters()
+		if len(chars) != len(c.characters) {
+			t.Fatalf("%d: expected characters %v, got %v", c.D, c.characters, chars)
+		}
+		for i := range chars {
+			if chars[i].Cmp(big.NewInt(c.characters[i])) != 0 {
+				t.Errorf("%d: expected characters %v, got %v", c.D, c.characters, chars)
+			}
+		}
+		if g.TwoRank() != len(c.characters)-1 {
+			t.Errorf("%d: 2-rank %d", c.D, g.TwoRank())
+		}
+	}
+	for _, D := range []int64{1, -12, 20, 0, 2, -7 * 9} {
+		if _, err := Genera(big.NewInt(D)); err != ErrNotFundamentalDiscriminant {
+			t.Errorf("%d: expected ErrNotFundamentalDiscriminant, got %v", D, err)
+		}
+	}
+
+	// -8 * 1000000007 * 1000000009 has characters beyond int64 products
+	// and too many classes to list.
+	p, q := big.NewInt(1000000007), big.NewInt(1000000009)
+	D := new(big.Int).Mul(p, q)
+	D.Mul(D, big.NewInt(-8))
+	g, err := Genera(D)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chars := g.Characters(); len(chars) != 3 || chars[0].Cmp(big.NewInt(8)) != 0 ||
+		chars[1].Cmp(new(big.Int).Neg(p)) != 0 || chars[2].Cmp(q) != 0 {
+		t.Errorf("%s: characters %v", D, chars)
+	}
+	if _, err := g.ReducedForms(); err != ErrClassGroupTooLarge {
+		t.Errorf("%s: expected ErrClassGroupTooLarge, got %v", D, err)
+	}
+}
+
+func TestReducedForms(t *testing.T) {
+	for _, D := range []int64{-3, -4, -23, -84, -1155, 5, 12, 40, 316, 1157} {
+		forms, err := ReducedForms(big.NewInt(D))
+		if err != nil {
+			t.Fatalf("%d: %v", D, err)
+		}
+		for _, f := range forms {
+			if !f.IsReduced() || !f.IsPrimitive() || f.Discriminant().Int64() != D {
+				t.Errorf("%d: %s is not a reduced primitive form", D, f)
+			}
+		}
+		if D < 0 && int64(len(forms)) != classNumberByForms(D) {
+			t.Errorf("%d: %d forms, class number %d", D, len(forms), classNumberByForms(D))
+		}
+	}
+	for _, D := range []int64{0, 2, -5, 16, 1} {
+		if _, err := ReducedForms(big.NewInt(D)); err != ErrNotDiscriminant {
+			t.Errorf("%d: expected ErrNotDiscriminant, got %v", D, err)
+		}
+	}
+	if _, err := ReducedForms(big.NewInt(-classNumberBSGSBound - 4)); err != ErrClassGroupTooLarge {
+		t.Errorf("expected ErrClassGroupTooLarge, got %v", err)
+	}
+}
+
+func TestGeneraImagQuad(t *testing.T) {
+	for D := int64(-3); D > -1000; D-- {
+		g, err := Genera(big.NewInt(D))
+		if err != nil {
+			continue
+		}
+		genera, err := g.ReducedForms()
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := classNumberByForms(D)
+		// Every genus has h / 2^(t-1) classes.
+		for i, forms := range genera {
+			if int64(len(forms))*int64(g.Count()) != h {
+				t.Fatalf("%d: genus %d has %d of %d classes", D, i, len(forms), h)
+			}
+		}
+		if !genera[0][0].Equal(PrincipalForm(big.NewInt(D))) {
+			t.Errorf("%d: %s is not principal", D, genera[0][0])
+		}
+		// The genus map is a homomorphism that kills squares; check it on
+		// the first few forms.
+		all := []*QuadraticForm{}
+		for _, forms := range genera {
+			all = append(all, forms...)
+		}
+		for i, f := range all {
+			gf := g.Genus(f)
+			if g.Genus(f.Square()) != 0 {
+				t.Errorf("%d: %s^2 is not in the principal genus", D, f)
+			}
+			for _, e := range all[i:] {
+				if g.Genus(f.Compose(e)) != gf^g.Genus(e) {
+					t.Fatalf("%d: genus of %s %s", D, f, e)
+				}
+			}
+			if i > 8 {
+				break
+			}
+		}
+		group, err := ClassGroupImagQuad(big.NewInt(D))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := 0
+		for _, d := range group.Invariants() {
+			if d%2 == 0 {
+				r++
+			}
+		}
+		if r != g.TwoRank() {
+			t.Errorf("%d: class group %s, 2-rank %d", D, group, g.TwoRank())
+		}
+	}
+}
+
+func TestGeneraRealQuad(t *testing.T) {
+	for D := int64(5); D < 1000; D++ {
+		g, err := Genera(big.NewInt(D))
+		if err != nil {
+			continue
+		}
+		genera, err := g.ReducedForms()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, forms := range genera {
+			if len(forms) == 0 {
+				t.Fatalf("%d: genus %d is empty", D, i)
+			}
+			// Forms in a cycle are equivalent, so in the same genus.
+			for _, f := range forms {
+				if !f.IsReduced() || g.Genus(f.Rho()) != i {
+					t.Fatalf("%d: %s and its neighbour in genus %d", D, f, i)
+				}
+			}
+		}
+		if g.Genus(PrincipalForm(big.NewInt(D)).Reduce()) != 0 {
+			t.Errorf("%d: principal form not in the principal genus", D)
+		}
+	}
+}
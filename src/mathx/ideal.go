@@ -0,0 +1,449 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// Bound on the number of elements tried when searching for a generator
+// of an ideal in fields other than imaginary quadratic ones.
+const principalSearchSize = 200000
+
+var ErrZeroIdeal = errors.New("mathx: zero ideal")
+var ErrPrincipalityUndecided = errors.New("mathx: unable to decide whether ideal is principal")
+
+// A nonzero fractional ideal of the ring of integers O of a number
+// field, stored as basis / denom, where basis is the lower triangular
+// Hermite normal form of an integral ideal with respect to the integral
+// basis, and denom is a positive integer coprime to the content of the
+// basis.
+type Ideal struct {
+	field *NumberField
+	o     *order
+	basis [][]big.Int
+	denom *big.Int
+}
+
+// A prime ideal with its exponent in a factorization.
+type IdealFactor struct {
+	Prime    *PrimeIdeal
+	Exponent int
+}
+
+// Create the ideal spanned over Z by rows / denom, which must be an
+// O-module of full rank containing mod * O, unless mod is nil.
+func newIdeal(k *NumberField, o *order, rows [][]big.Int, denom, mod *big.Int) *Ideal {
+	h := hnfLower(rows, o.degree(), mod)
+	g := new(big.Int).Set(denom)
+	for i := range h {
+		for j := range h[i] {
+			g.GCD(nil, nil, g, new(big.Int).Abs(&h[i][j]))
+		}
+	}
+	I := &Ideal{field: k, o: o, basis: h, denom: new(big.Int).Quo(denom, g)}
+	for i := range h {
+		for j := range h[i] {
+			h[i][j].Quo(&h[i][j], g)
+		}
+	}
+	return I
+}
+
+// The ring of integers, as an ideal.
+func (k *NumberField) UnitIdeal() (*Ideal, error) {
+	return k.Ideal(k.One())
+}
+
+// Create the ideal generated by the given elements, which must not all
+// be zero.
+func (k *NumberField) Ideal(gens ...*NumberFieldElement) (*Ideal, error) {
+	o, err := k.ringOfIntegers()
+	if err != nil {
+		return nil, err
+	}
+	var I *Ideal
+	for _, x := range gens {
+		if x.IsZero() {
+			continue
+		}
+		c, d := k.orderCoordinates(o, x)
+		n := o.degree()
+		rows := make([][]big.Int, n)
+		for i := range rows {
+			rows[i] = o.mul(c, unitVector(n, i))
+		}
+		J := newIdeal(k, o, rows, d, nil)
+		if I == nil {
+			I = J
+		} else {
+			I = I.Add(J)
+		}
+	}
+	if I == nil {
+		return nil, ErrZeroIdeal
+	}
+	return I, nil
+}
+
+// Return P as an ideal.
+func (P *PrimeIdeal) Ideal() *Ideal {
+	o, _ := P.field.ringOfIntegers()
+	return newIdeal(P.field, o, P.basis, intOne, P.p)
+}
+
+func (I *Ideal) Field() *NumberField {
+	return I.field
+}
+
+// Return a Z-basis of I.
+func (I *Ideal) Basis() []*NumberFieldElement {
+	basis := make([]*NumberFieldElement, len(I.basis))
+	for i := range basis {
+		basis[i] = I.field.elementFromOrder(I.o, I.basis[i]).MulRat(new(big.Rat).SetFrac(intOne, I.denom))
+	}
+	return basis
+}
+
+func (I *Ideal) IsIntegral() bool {
+	return I.denom.Cmp(intOne) == 0
+}
+
+func (I *Ideal) Equal(J *Ideal) bool {
+	if I.denom.Cmp(J.denom) != 0 {
+		return false
+	}
+	for i := range I.basis {
+		for j := range I.basis[i] {
+			if I.basis[i][j].Cmp(&J.basis[i][j]) != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Tell if x lies in I.
+func (I *Ideal) Contains(x *NumberFieldElement) bool {
+	c, d := I.field.orderCoordinates(I.o, x)
+	// x = c / d is in basis / denom iff c denom / d is in basis.
+	r := new(big.Int)
+	for i := range c {
+		c[i].Mul(&c[i], I.denom)
+		c[i].QuoRem(&c[i], d, r)
+		if r.Sign() != 0 {
+			return false
+		}
+	}
+	return solveLower(I.basis, c) != nil
+}
+
+// The norm of the integral part, the index of basis in Z^n.
+func (I *Ideal) numeratorNorm() *big.Int {
+	d := big.NewInt(1)
+	for i := range I.basis {
+		d.Mul(d, &I.basis[i][i])
+	}
+	return d
+}
+
+// Compute the norm of I, the index [O : I] extended multiplicatively
+// to fractional ideals.
+func (I *Ideal) Norm() *big.Rat {
+	d := new(big.Int).Exp(I.denom, big.NewInt(int64(len(I.basis))), nil)
+	return new(big.Rat).SetFrac(I.numeratorNorm(), d)
+}
+
+func (I *Ideal) Mul(J *Ideal) *Ideal {
+	rows := [][]big.Int{}
+	for i := range I.basis {
+		for j := range J.basis {
+			rows = append(rows, I.o.mul(I.basis[i], J.basis[j]))
+		}
+	}
+	mod := new(big.Int).Mul(I.numeratorNorm(), J.numeratorNorm())
+	return newIdeal(I.field, I.o, rows, new(big.Int).Mul(I.denom, J.denom), mod)
+}
+
+// Compute I + J, the smallest ideal containing both.
+func (I *Ideal) Add(J *Ideal) *Ideal {
+	rows := [][]big.Int{}
+	for _, r := range I.basis {
+		rows = append(rows, scaleVector(r, J.denom))
+	}
+	for _, r := range J.basis {
+		rows = append(rows, scaleVector(r, I.denom))
+	}
+	mod := new(big.Int).Mul(I.numeratorNorm(), J.denom)
+	return newIdeal(I.field, I.o, rows, new(big.Int).Mul(I.denom, J.denom), mod)
+}
+
+// Compute the intersection of I and J, using (I n J)* = I* + J* for the
+// trace dual.
+func (I *Ideal) Intersect(J *Ideal) *Ideal {
+	return I.traceDual().Add(J.traceDual()).traceDual()
+}
+
+// Compute the inverse of I. With the trace dual L* = {x : Tr(xL) in Z},
+// I* is the inverse of I times the different, and O* the inverse of the
+// different, so I^-1 = (I O*)*.
+func (I *Ideal) Inverse() *Ideal {
+	one := newIdeal(I.field, I.o, identityMatrix(len(I.basis)), intOne, intOne)
+	return I.Mul(one.traceDual()).traceDual()
+}
+
+// Compute I^e for any integer e.
+func (I *Ideal) Pow(e int) *Ideal {
+	s := I
+	if e < 0 {
+		s = I.Inverse()
+		e = -e
+	}
+	r := newIdeal(I.field, I.o, identityMatrix(len(I.basis)), intOne, intOne)
+	for ; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			r = r.Mul(s)
+		}
+		if e > 1 {
+			s = s.Mul(s)
+		}
+	}
+	return r
+}
+
+// Compute the trace dual {x : Tr(xI) in Z}. If B holds the basis of I
+// and T the trace form on the integral basis, the dual basis is the
+// transpose of (B T)^-1.
+func (I *Ideal) traceDual() *Ideal {
+	n := len(I.basis)
+	t := I.o.traceMatrix()
+	m := make([][]big.Rat, n)
+	s := new(big.Int)
+	for i := range m {
+		m[i] = make([]big.Rat, n)
+		for j := 0; j < n; j++ {
+			c := new(big.Int)
+			for k := 0; k < n; k++ {
+				c.Add(c, s.Mul(&I.basis[i][k], &t[k][j]))
+			}
+			m[i][j].SetFrac(c, I.denom)
+		}
+	}
+	inv := ratInverse(m)
+	dual := make([][]big.Rat, n)
+	for i := range dual {
+		dual[i] = make([]big.Rat, n)
+		for j := range dual[i] {
+			dual[i][j].Set(&inv[j][i])
+		}
+	}
+	rows, d := clearDenominators(dual)
+	mod := new(big.Int).Abs(determinant(rows))
+	return newIdeal(I.field, I.o, rows, d, mod)
+}
+
+// The matrix of Tr(w_i w_j) on the integral basis.
+func (o *order) traceMatrix() [][]big.Int {
+	n := o.degree()
+	tr := make([]big.Int, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			tr[i].Add(&tr[i], &o.mult[i][j][j])
+		}
+	}
+	t := newMatrix(n, n)
+	s := new(big.Int)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			for k := 0; k < n; k++ {
+				t[i][j].Add(&t[i][j], s.Mul(&o.mult[i][j][k], &tr[k]))
+			}
+		}
+	}
+	return t
+}
+
+func scaleVector(v []big.Int, c *big.Int) []big.Int {
+	w := make([]big.Int, len(v))
+	for i := range v {
+		w[i].Mul(&v[i], c)
+	}
+	return w
+}
+
+// Factor I into prime ideals, ordered by the rational prime below them.
+// Valuations come from testing containment in powers of each prime
+// above the primes dividing the norm and the denominator.
+func (I *Ideal) Factor() ([]IdealFactor, error) {
+	m := new(big.Int).Mul(I.numeratorNorm(), I.denom)
+	primes, err := factorBig(m)
+	if err != nil {
+		return nil, err
+	}
+	num := newIdeal(I.field, I.o, I.basis, intOne, I.numeratorNorm())
+	factors := []IdealFactor{}
+	for _, q := range primes {
+		ideals, err := I.field.PrimeDecomposition(q.prime)
+		if err != nil {
+			return nil, err
+		}
+		vd := 0
+		for d := new(big.Int).Set(I.denom); new(big.Int).Mod(d, q.prime).Sign() == 0; d.Quo(d, q.prime) {
+			vd++
+		}
+		for _, P := range ideals {
+			v := num.valuation(P) - P.e*vd
+			if v != 0 {
+				factors = append(factors, IdealFactor{P, v})
+			}
+		}
+	}
+	return factors, nil
+}
+
+// The valuation at P of an integral ideal, the largest k with I in P^k.
+func (I *Ideal) valuation(P *PrimeIdeal) int {
+	p := P.Ideal()
+	power := p
+	v := 0
+	for I.isSubset(power) {
+		v++
+		power = power.Mul(p)
+	}
+	return v
+}
+
+// Tell if I is contained in J.
+func (I *Ideal) isSubset(J *Ideal) bool {
+	r := new(big.Int)
+	for _, row := range I.basis {
+		c := scaleVector(row, J.denom)
+		for i := range c {
+			c[i].QuoRem(&c[i], I.denom, r)
+			if r.Sign() != 0 {
+				return false
+			}
+		}
+		if solveLower(J.basis, c) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Tell if I is principal, returning a generator if it is. This is
+// decided by enumerating the elements of I of the right norm, which is
+// exhaustive in imaginary quadratic fields, where the norm is a positive
+// definite quadratic form; elsewhere only small elements are tried and
+// ErrPrincipalityUndecided is returned if none generates I.
+func (I *Ideal) IsPrincipal() (bool, *NumberFieldElement, error) {
+	k := I.field
+	n := len(I.basis)
+	scale := new(big.Rat).SetFrac(intOne, I.denom)
+	norm := I.numeratorNorm()
+	if n == 1 {
+		return true, k.NewElement([]*big.Rat{new(big.Rat).SetFrac(norm, I.denom)}), nil
+	}
+	if n == 2 && k.polynomial.Discriminant().Sign() < 0 {
+		u, v := I.normFormUnit(norm)
+		if u == nil {
+			return false, nil, nil
+		}
+		x := make([]big.Int, 2)
+		for j := range x {
+			x[j].Mul(u, &I.basis[0][j])
+			x[j].Add(&x[j], new(big.Int).Mul(v, &I.basis[1][j]))
+		}
+		return true, k.elementFromOrder(I.o, x).MulRat(scale), nil
+	}
+
+	// Try the vectors with coordinates in [-b, b], for the largest b
+	// with (2b + 1)^n within the search size.
+	b := 1
+	for {
+		size := 1
+		for i := 0; i < n; i++ {
+			size *= 2*b + 3
+		}
+		if size > principalSearchSize {
+			break
+		}
+		b++
+	}
+	c := make([]int, n)
+	for i := range c {
+		c[i] = -b
+	}
+	for {
+		x := make([]big.Int, n)
+		for i := range c {
+			if c[i] == 0 {
+				continue
+			}
+			ci := big.NewInt(int64(c[i]))
+			for j := range x {
+				x[j].Add(&x[j], new(big.Int).Mul(ci, &I.basis[i][j]))
+			}
+		}
+		if !isZeroVector(x) {
+			d := determinant(I.o.multiplicationMatrix(x))
+			if d.Abs(d).Cmp(norm) == 0 {
+				return true, k.elementFromOrder(I.o, x).MulRat(scale), nil
+			}
+		}
+		i := 0
+		for i < n && c[i] == b {
+			c[i] = -b
+			i++
+		}
+		if i == n {
+			break
+		}
+		c[i]++
+	}
+	return false, nil, ErrPrincipalityUndecided
+}
+
+// For an integral ideal of an imaginary quadratic field with basis
+// b_0, b_1 and norm m, N(u b_0 + v b_1) / m is a positive definite form
+// of discriminant D. The ideal is principal iff this form reduces to the
+// principal form; the reduction then gives (u, v) with form value 1, or
+// nil if there is none.
+func (I *Ideal) normFormUnit(m *big.Int) (*big.Int, *big.Int) {
+	o := I.o
+	b0, b1 := I.basis[0], I.basis[1]
+	a := determinant(o.multiplicationMatrix(b0))
+	c := determinant(o.multiplicationMatrix(b1))
+	b := determinant(o.multiplicationMatrix([]big.Int{
+		*new(big.Int).Add(&b0[0], &b1[0]), *new(big.Int).Add(&b0[1], &b1[1])}))
+	b.Sub(b, a).Sub(b, c)
+	f := &QuadraticForm{a.Quo(a, m), b.Quo(b, m), c.Quo(c, m)}
+	g, t := f.reduceTransform()
+	if g.a.Cmp(intOne) != 0 {
+		return nil, nil
+	}
+	return t[0][0], t[1][0]
+}
+
+func (I *Ideal) String() string {
+	s := []string{}
+	for _, x := range I.Basis() {
+		s = append(s, x.String())
+	}
+	return "(" + strings.Join(s, ", ") + ")"
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import "math/big"
+
+// Return the residue degrees of the primes above p, from the factors of
+// the defining polynomial modulo p when p does not divide the index of
+// the equation order.
+func (k *NumberField) ResidueDegrees(p int64) ([]int, error) {
+	o, err := k.ringOfIntegers()
+	if err != nil {
+		return nil, err
+	}
+	bp := big.NewInt(p)
+	degrees := []int{}
+	if new(big.Int).Mod(o.index(), bp).Sign() != 0 {
+		_, factors := o.poly.ModP(bp).Factor()
+		for _, f := range factors {
+			degrees = append(degrees, f.Factor.Degree())
+		}
+		return degrees, nil
+	}
+	ideals, err := k.PrimeDecomposition(bp)
+	if err != nil {
+		return nil, err
+	}
+	for _, P := range ideals {
+		degrees = append(degrees, P.ResidueDegree())
+	}
+	return degrees, nil
+}
+
+// Count the ideals a_n of norm n for n <= N, the coefficients of
+// zeta_k(s) = sum a_n n^-s, multiplying the local factors
+// prod_{P | p} 1 / (1 - X^f(P)) with X = p^-s.
+func (k *NumberField) IdealCounts(N int) ([]int64, error) {
+	a := make([]int64, N+1)
+	if N < 1 {
+		return a, nil
+	}
+	a[1] = 1
+	for _, p := range sievePrimes(N) {
+		degrees, err := k.ResidueDegrees(p)
+		if err != nil {
+			return nil, err
+		}
+		// c[j] counts the ideals of norm p^j.
+		c := []int64{1}
+		for q := p; q <= int64(N); q *= p {
+			c = append(c, 0)
+		}
+		for _, f := range degrees {
+			for j := f; j < len(c); j++ {
+				c[j] += c[j-f]
+			}
+		}
+		// Extend a multiplicatively from the n prime to p.
+		for m := N / int(p); m >= 1; m-- {
+			if a[m] == 0 || m%int(p) == 0 {
+				continue
+			}
+			q := int(p)
+			for j := 1; j < len(c) && m*q <= N; j++ {
+				a[m*q] = a[m] * c[j]
+				q *= int(p)
+			}
+		}
+	}
+	return a, nil
+}
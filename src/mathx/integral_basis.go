@@ -0,0 +1,383 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"errors"
+	"math/big"
+)
+
+var ErrNotSquareFree = errors.New("mathx: defining polynomial is not square-free")
+
+// An order of a number field, with Z-basis w_0, ..., w_{n-1} where
+// w_i = (basis[i][0] + basis[i][1] t + ... + basis[i][i] t^i) / denom
+// and t is a root of the monic polynomial poly.
+type order struct {
+	poly  *IntPolynomial
+	basis [][]big.Int
+	denom *big.Int
+	// mult[i][j] holds the coordinates of w_i w_j.
+	mult [][][]big.Int
+}
+
+// The equation order Z[t].
+func equationOrder(poly *IntPolynomial) *order {
+	n := poly.Degree()
+	basis := newMatrix(n, n)
+	for i := range basis {
+		basis[i][i].SetInt64(1)
+	}
+	return newOrder(poly, basis, big.NewInt(1))
+}
+
+// Create the order with the given lower triangular basis, removing any
+// common factor of the basis and the denominator.
+func newOrder(poly *IntPolynomial, basis [][]big.Int, denom *big.Int) *order {
+	g := new(big.Int).Set(denom)
+	for i := range basis {
+		for j := range basis[i] {
+			g.GCD(nil, nil, g, new(big.Int).Abs(&basis[i][j]))
+		}
+	}
+	o := &order{poly: poly, basis: copyMatrix(basis), denom: new(big.Int).Quo(denom, g)}
+	for i := range o.basis {
+		for j := range o.basis[i] {
+			o.basis[i][j].Quo(&o.basis[i][j], g)
+		}
+	}
+	n := len(basis)
+	o.mult = make([][][]big.Int, n)
+	for i := range o.mult {
+		o.mult[i] = make([][]big.Int, n)
+	}
+	d2 := new(big.Int).Mul(o.denom, o.denom)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			c := o.coordinates(o.mulTheta(o.basis[i], o.basis[j]), d2)
+			if c == nil {
+				panic("mathx: basis does not span an order")
+			}
+			o.mult[i][j] = c
+			o.mult[j][i] = c
+		}
+	}
+	return o
+}
+
+func (o *order) degree() int {
+	return len(o.basis)
+}
+
+// Multiply two polynomials in t, given by their coefficients, modulo
+// the defining polynomial.
+func (o *order) mulTheta(a, b []big.Int) []big.Int {
+	p := &IntPolynomial{coeffs: mulCoeffs(a, b)}
+	_, r, _ := p.trim().DivMod(o.poly)
+	c := make([]big.Int, o.degree())
+	for i := range r.coeffs {
+		c[i].Set(&r.coeffs[i])
+	}
+	return c
+}
+
+// Write (v_0 + v_1 t + ... ) / den in terms of the basis. Returns nil
+// if the element is not in the order.
+func (o *order) coordinates(v []big.Int, den *big.Int) []big.Int {
+	w := make([]big.Int, len(v))
+	r := new(big.Int)
+	for i := range v {
+		w[i].Mul(&v[i], o.denom)
+		w[i].QuoRem(&w[i], den, r)
+		if r.Sign() != 0 {
+			return nil
+		}
+	}
+	return solveLower(o.basis, w)
+}
+
+// Multiply two elements given by their coordinates.
+func (o *order) mul(a, b []big.Int) []big.Int {
+	n := o.degree()
+	c := make([]big.Int, n)
+	t := new(big.Int)
+	for i := 0; i < n; i++ {
+		if a[i].Sign() == 0 {
+			continue
+		}
+		for j := 0; j < n; j++ {
+			if b[j].Sign() == 0 {
+				continue
+			}
+			t.Mul(&a[i], &b[j])
+			for k := 0; k < n; k++ {
+				c[k].Add(&c[k], new(big.Int).Mul(t, &o.mult[i][j][k]))
+			}
+		}
+	}
+	return c
+}
+
+// Compute a^e with coordinates reduced modulo p.
+func (o *order) powMod(a []big.Int, e, p *big.Int) []big.Int {
+	// The first basis element is always 1.
+	n := o.degree()
+	r := make([]big.Int, n)
+	r[0].SetInt64(1)
+	s := make([]big.Int, n)
+	for i := range a {
+		s[i].Mod(&a[i], p)
+	}
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r = reduceVector(o.mul(r, r), p)
+		if e.Bit(i) == 1 {
+			r = reduceVector(o.mul(r, s), p)
+		}
+	}
+	return r
+}
+
+func reduceVector(v []big.Int, p *big.Int) []big.Int {
+	for i := range v {
+		v[i].Mod(&v[i], p)
+	}
+	return v
+}
+
+// The index [O : Z[t]] = denom^n / (basis[0][0] ... basis[n-1][n-1]).
+func (o *order) index() *big.Int {
+	n := o.degree()
+	d := new(big.Int).Exp(o.denom, big.NewInt(int64(n)), nil)
+	for i := 0; i < n; i++ {
+		d.Quo(d, &o.basis[i][i])
+	}
+	return d
+}
+
+// The discriminant disc(poly) / [O : Z[t]]^2.
+func (o *order) discriminant() *big.Int {
+	d := o.poly.Discriminant()
+	i := o.index()
+	i.Mul(i, i)
+	return d.Quo(d, i)
+}
+
+// Compute the HNF basis of the p-radical I_p of O, the kernel of
+// x -> x^q on O/pO, for q a power of p no smaller than n.
+func (o *order) radical(p *big.Int) [][]big.Int {
+	n := o.degree()
+	q := new(big.Int).Set(p)
+	for q.Cmp(big.NewInt(int64(n))) < 0 {
+		q.Mul(q, p)
+	}
+	a := newMatrix(n, n)
+	for i := 0; i < n; i++ {
+		y := o.powMod(unitVector(n, i), q, p)
+		for j := 0; j < n; j++ {
+			a[j][i].Set(&y[j])
+		}
+	}
+	return hnfLower(kernelModP(a, p), n, p)
+}
+
+func unitVector(n, i int) []big.Int {
+	e := make([]big.Int, n)
+	e[i].SetInt64(1)
+	return e
+}
+
+// Enlarge the order until it is maximal at p.
+// Cohen, Alg. 6.1.8 (Round 2), steps 2 to 6.
+func (o *order) pMaximal(p *big.Int) *order {
+	n := o.degree()
+	p2 := new(big.Int).Mul(p, p)
+	for new(big.Int).Mod(o.discriminant(), p2).Sign() == 0 {
+		radical := o.radical(p)
+
+		// U/pO is the kernel of O/pO -> End(I_p/pI_p).
+		c := newMatrix(n*n, n)
+		for i := 0; i < n; i++ {
+			e := unitVector(n, i)
+			for j := 0; j < n; j++ {
+				y := solveLower(radical, o.mul(e, radical[j]))
+				for k := 0; k < n; k++ {
+					c[j*n+k][i].Mod(&y[k], p)
+				}
+			}
+		}
+		u := hnfLower(kernelModP(c, p), n, p)
+		if isDiagonalOf(u, p) {
+			break
+		}
+
+		// The new order is U/p.
+		rows := newMatrix(n, n)
+		t := new(big.Int)
+		for i := 0; i < n; i++ {
+			for k := 0; k <= i; k++ {
+				for j := 0; j <= k; j++ {
+					t.Mul(&u[i][k], &o.basis[k][j])
+					rows[i][j].Add(&rows[i][j], t)
+				}
+			}
+		}
+		o = newOrder(o.poly, hnfLower(rows, n, nil), new(big.Int).Mul(o.denom, p))
+	}
+	return o
+}
+
+// Test whether u is p times the identity.
+func isDiagonalOf(u [][]big.Int, p *big.Int) bool {
+	for i := range u {
+		for j := range u[i] {
+			if i == j && u[i][j].Cmp(p) != 0 || i != j && u[i][j].Sign() != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Compute the maximal order of k with the Round 2 algorithm, in terms
+// of t = a alpha, where a is the leading coefficient of the defining
+// polynomial and alpha is its root. t is a root of the monic polynomial
+// a^(n-1) f(x/a).
+func (k *NumberField) ringOfIntegers() (*order, error) {
+	if k.maximalOrder != nil {
+		return k.maximalOrder, nil
+	}
+	f := k.polynomial
+	n := f.Degree()
+	a := f.LeadingCoeff()
+	g := new(IntPolynomial)
+	g.coeffs = make([]big.Int, n+1)
+	s := big.NewInt(1)
+	for i := n - 1; i >= 0; i-- {
+		g.coeffs[i].Mul(&f.coeffs[i], s)
+		s.Mul(s, a)
+	}
+	g.coeffs[n].SetInt64(1)
+
+	d := g.Discriminant()
+	if d.Sign() == 0 {
+		return nil, ErrNotSquareFree
+	}
+	factors, err := factorBig(d)
+	if err != nil {
+		return nil, err
+	}
+	o := equationOrder(g)
+	for _, fac := range factors {
+		if fac.exponent >= 2 {
+			o = o.pMaximal(fac.prime)
+		}
+	}
+	k.maximalOrder = o
+	return o, nil
+}
+
+// Convert coordinates with respect to the integral basis to an element
+// of k.
+func (k *NumberField) elementFromOrder(o *order, c []big.Int) *NumberFieldElement {
+	n := o.degree()
+	a := k.polynomial.LeadingCoeff()
+	coords := make([]big.Rat, n)
+	s := big.NewInt(1)
+	t := new(big.Int)
+	for j := 0; j < n; j++ {
+		num := new(big.Int)
+		for i := j; i < n; i++ {
+			num.Add(num, t.Mul(&c[i], &o.basis[i][j]))
+		}
+		num.Mul(num, s)
+		coords[j].SetFrac(num, o.denom)
+		s.Mul(s, a)
+	}
+	return k.newElement(coords)
+}
+
+// Write an element of k in terms of the integral basis, as an integer
+// vector over a positive denominator.
+func (k *NumberField) orderCoordinates(o *order, x *NumberFieldElement) ([]big.Int, *big.Int) {
+	n := o.degree()
+	a := k.polynomial.LeadingCoeff()
+	// First in terms of powers of t = a alpha, then solve the
+	// triangular system for the basis.
+	t := make([]big.Rat, n)
+	s := big.NewInt(1)
+	for j := range x.coords {
+		t[j].SetFrac(s, intOne)
+		t[j].Quo(&x.coords[j], &t[j])
+		s.Mul(s, a)
+	}
+	c := make([]big.Rat, n)
+	r := new(big.Rat)
+	for j := n - 1; j >= 0; j-- {
+		c[j].Mul(&t[j], new(big.Rat).SetInt(o.denom))
+		for i := j + 1; i < n; i++ {
+			c[j].Sub(&c[j], r.Mul(&c[i], new(big.Rat).SetInt(&o.basis[i][j])))
+		}
+		c[j].Quo(&c[j], new(big.Rat).SetInt(&o.basis[j][j]))
+	}
+	m, d := clearDenominators([][]big.Rat{c})
+	return m[0], d
+}
+
+// Compute an integral basis of the ring of integers of k. Element i of
+// the result holds the coordinates of the i-th basis element with
+// respect to 1, alpha, ..., alpha^(n-1), where alpha is the root of the
+// defining polynomial. The basis element i has degree i in alpha.
+func (k *NumberField) IntegralBasis() ([][]*big.Rat, error) {
+	o, err := k.ringOfIntegers()
+	if err != nil {
+		return nil, err
+	}
+	n := o.degree()
+	a := k.polynomial.LeadingCoeff()
+	basis := make([][]*big.Rat, n)
+	for i := range basis {
+		basis[i] = make([]*big.Rat, n)
+		s := big.NewInt(1)
+		for j := range basis[i] {
+			c := new(big.Int).Mul(&o.basis[i][j], s)
+			basis[i][j] = new(big.Rat).SetFrac(c, o.denom)
+			s.Mul(s, a)
+		}
+	}
+	return basis, nil
+}
+
+// Compute the index [O_K : Z[alpha]] of the equation order in the
+// ring of integers. When the defining polynomial is not monic, alpha is
+// replaced by the algebraic integer a alpha, where a is the leading
+// coefficient.
+func (k *NumberField) Index() (*big.Int, error) {
+	o, err := k.ringOfIntegers()
+	if err != nil {
+		return nil, err
+	}
+	return o.index(), nil
+}
+
+// Compute the discriminant of the field, that is, of its ring of
+// integers. Returns nil if it cannot be computed, because the defining
+// polynomial is not square-free or its discriminant cannot be factored.
+func (k *NumberField) Discriminant() *big.Int {
+	o, err := k.ringOfIntegers()
+	if err != nil {
+		return nil
+	}
+	return o.discriminant()
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"errors"
+	"math/big"
+)
+
+var ErrNotFundamentalDiscriminant = errors.New("mathx: not a fundamental discriminant")
+
+// Compute the Kronecker symbol (a / n) for any integers a and n,
+// extending the Jacobi symbol by (a / -1) = sign(a), (a / 0) = 1 if
+// a = +-1 and 0 otherwise, and (a / 2) = 0, 1, -1 as a is even, +-1 or
+// +-3 modulo 8.
+// Cohen, Alg. 1.4.10.
+func Kronecker(a, n *big.Int) int {
+	if n.Sign() == 0 {
+		if a.CmpAbs(intOne) == 0 {
+			return 1
+		}
+		return 0
+	}
+	k := 1
+	m := new(big.Int).Abs(n)
+	if n.Sign() < 0 && a.Sign() < 0 {
+		k = -1
+	}
+	if v := m.TrailingZeroBits(); v > 0 {
+		if a.Bit(0) == 0 {
+			return 0
+		}
+		m.Rsh(m, v)
+		if v&1 == 1 {
+			if r := new(big.Int).And(a, big.NewInt(7)).Int64(); r == 3 || r == 5 {
+				k = -k
+			}
+		}
+	}
+	if m.Cmp(intOne) == 0 {
+		return k
+	}
+	return k * big.Jacobi(new(big.Int).Mod(a, m), m)
+}
+
+// Compute the Jacobi symbol (a / n) for odd n > 0.
+func Jacobi(a, n *big.Int) int {
+	if n.Sign() <= 0 || n.Bit(0) == 0 {
+		panic("mathx: Jacobi symbol needs an odd positive modulus")
+	}
+	return big.Jacobi(new(big.Int).Mod(a, n), n)
+}
+
+// Compute the Legendre symbol (a / p) for an odd prime p.
+func Legendre(a, p *big.Int) int {
+	return Jacobi(a, p)
+}
+
+// Compute the Kronecker symbol (a / n) on machine integers.
+func Kronecker64(a, n int64) int {
+	if n == 0 {
+		if a == 1 || a == -1 {
+			return 1
+		}
+		return 0
+	}
+	k := 1
+	m := uint64(n)
+	if n < 0 {
+		// Negating in uint64 also works for n = math.MinInt64.
+		m = -m
+		if a < 0 {
+			k = -1
+		}
+	}
+	if m&1 == 0 {
+		if a&1 == 0 {
+			return 0
+		}
+		for m&1 == 0 {
+			m >>= 1
+			if r := a & 7; r == 3 || r == 5 {
+				k = -k
+			}
+		}
+	}
+	return k * jacobiUint64(a, m)
+}
+
+// Compute the Jacobi symbol (a / n) for odd n > 0 on machine integers.
+func Jacobi64(a, n int64) int {
+	if n <= 0 || n&1 == 0 {
+		panic("mathx: Jacobi symbol needs an odd positive modulus")
+	}
+	return jacobiUint64(a, uint64(n))
+}
+
+// Compute the Legendre symbol (a / p) for an odd prime p on machine
+// integers.
+func Legendre64(a, p int64) int {
+	return Jacobi64(a, p)
+}
+
+// Compute (a / n) for odd n > 0 by quadratic reciprocity.
+func jacobiUint64(a int64, n uint64) int {
+	var x uint64
+	if a >= 0 {
+		x = uint64(a) % n
+	} else {
+		// -a - 1 does not overflow, even for a = math.MinInt64.
+		x = n - 1 - uint64(-(a+1))%n
+	}
+	k := 1
+	for x != 0 {
+		for x&1 == 0 {
+			x >>= 1
+			if r := n & 7; r == 3 || r == 5 {
+				k = -k
+			}
+		}
+		x, n = n, x
+		if x&3 == 3 && n&3 == 3 {
+			k = -k
+		}
+		x %= n
+	}
+	if n == 1 {
+		return k
+	}
+	return 0
+}
+
+// The Kronecker character n -> (D / n) of a fundamental discriminant D,
+// the primitive quadratic Dirichlet character of conductor |D|.
+type QuadraticCharacter struct {
+	d *big.Int
+}
+
+func NewQuadraticCharacter(D *big.Int) (*QuadraticCharacter, error) {
+	if !IsFundamentalDiscriminant(D) {
+		return nil, ErrNotFundamentalDiscriminant
+	}
+	return &QuadraticCharacter{new(big.Int).Set(D)}, nil
+}
+
+func (chi *QuadraticCharacter) Discriminant() *big.Int {
+	return new(big.Int).Set(chi.d)
+}
+
+func (chi *QuadraticCharacter) Conductor() *big.Int {
+	return new(big.Int).Abs(chi.d)
+}
+
+// Tell if chi(-1) = 1, that is, if D > 0.
+func (chi *QuadraticCharacter) IsEven() bool {
+	return chi.d.Sign() > 0
+}
+
+func (chi *QuadraticCharacter) Eval(n *big.Int) int {
+	return Kronecker(chi.d, n)
+}
+
+func (chi *QuadraticCharacter) Eval64(n int64) int {
+	if chi.d.IsInt64() {
+		return Kronecker64(chi.d.Int64(), n)
+	}
+	return Kronecker(chi.d, big.NewInt(n))
+}
+
+// Return chi(0), chi(1), ..., chi(n - 1), filling in one period and
+// repeating it when n exceeds the conductor.
+func (chi *QuadraticCharacter) Values(n int) []int {
+	values := make([]int, n)
+	period := n
+	if f := chi.Conductor(); f.IsInt64() && f.Int64() < int64(n) {
+		period = int(f.Int64())
+	}
+	for i := 0; i < n; i++ {
+		if i < period {
+			values[i] = chi.Eval64(int64(i))
+		} else {
+			values[i] = values[i-period]
+		}
+	}
+	return values
+}
+
+func (chi *QuadraticCharacter) String() string {
+	return "(" + chi.d.String() + " / .)"
+}
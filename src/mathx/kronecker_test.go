@@ -0,0 +1,126 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+// Compute (a / n) from the definition: Euler's criterion at odd primes,
+// and multiplicativity in n.
+func kroneckerSlow(a, n int64) int {
+	if n == 0 {
+		if a == 1 || a == -1 {
+			return 1
+		}
+		return 0
+	}
+	k := 1
+	for _, f := range Factorization64(n) {
+		var s int
+		switch {
+		case f.prime == -1:
+			s = 1
+			if a < 0 {
+				s = -1
+			}
+		case f.prime == 2:
+			switch PosMod(a, 8) {
+			case 1, 7:
+				s = 1
+			case 3, 5:
+				s = -1
+			}
+		default:
+			p := big.NewInt(f.prime)
+			e := new(big.Int).Exp(big.NewInt(PosMod(a, f.prime)), big.NewInt((f.prime-1)/2), p).Int64()
+			switch e {
+			case 1:
+				s = 1
+			case f.prime - 1:
+				s = -1
+			}
+		}
+		for i := 0; i < f.exponent; i++ {
+			k *= s
+		}
+	}
+	return k
+}
+
+func TestKronecker(t *testing.T) {
+	for a := int64(-40); a <= 40; a++ {
+		for n := int64(-40); n <= 40; n++ {
+			expect := kroneckerSlow(a, n)
+			if k := Kronecker(big.NewInt(a), big.NewInt(n)); k != expect {
+				t.Errorf("(%d / %d): expected %d, got %d", a, n, expect, k)
+			}
+			if k := Kronecker64(a, n); k != expect {
+				t.Errorf("(%d / %d): expected %d, got %d from Kronecker64", a, n, expect, k)
+			}
+			if n > 0 && n&1 == 1 && Jacobi64(a, n) != expect {
+				t.Errorf("(%d / %d): wrong Jacobi64", a, n)
+			}
+		}
+	}
+
+	extremes := []int64{math.MinInt64, math.MinInt64 + 1, -3, 5, math.MaxInt64, 1<<62 + 1}
+	for _, a := range extremes {
+		for _, n := range extremes {
+			if k, b := Kronecker64(a, n), Kronecker(big.NewInt(a), big.NewInt(n)); k != b {
+				t.Errorf("(%d / %d): Kronecker64 gives %d, Kronecker %d", a, n, k, b)
+			}
+		}
+	}
+
+	p, _ := new(big.Int).SetString("170141183460469231731687303715884105727", 10)
+	if Legendre(big.NewInt(-1), p) != -1 || Legendre(big.NewInt(2), p) != 1 {
+		t.Errorf("wrong Legendre symbols modulo 2^127 - 1")
+	}
+}
+
+func TestQuadraticCharacter(t *testing.T) {
+	for _, D := range []int64{-3, -4, -8, -84, 5, 8, 12, 5 * 7 * 11 * 13} {
+		chi, err := NewQuadraticCharacter(big.NewInt(D))
+		if err != nil {
+			t.Fatalf("%d: %v", D, err)
+		}
+		if chi.IsEven() != (chi.Eval64(-1) == 1) {
+			t.Errorf("%s: wrong parity", chi)
+		}
+		f := int(chi.Conductor().Int64())
+		values := chi.Values(3 * f)
+		sum := 0
+		for n, v := range values {
+			if v != chi.Eval64(int64(n)) || v != chi.Eval(big.NewInt(int64(n))) {
+				t.Errorf("%s: wrong value at %d", chi, n)
+			}
+			if n < f {
+				sum += v
+			}
+		}
+		if sum != 0 {
+			t.Errorf("%s: values over a period sum to %d", chi, sum)
+		}
+	}
+	for _, D := range []int64{-16, 0, 7, -12} {
+		if _, err := NewQuadraticCharacter(big.NewInt(D)); err != ErrNotFundamentalDiscriminant {
+			t.Errorf("%d: expected ErrNotFundamentalDiscriminant", D)
+		}
+	}
+}
@@ -0,0 +1,442 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"math/big"
+)
+
+// Matrices are stored as slices of rows.
+
+func newMatrix(rows, cols int) [][]big.Int {
+	m := make([][]big.Int, rows)
+	for i := range m {
+		m[i] = make([]big.Int, cols)
+	}
+	return m
+}
+
+func identityMatrix(n int) [][]big.Int {
+	m := newMatrix(n, n)
+	for i := range m {
+		m[i][i].SetInt64(1)
+	}
+	return m
+}
+
+func copyMatrix(a [][]big.Int) [][]big.Int {
+	if len(a) == 0 {
+		return nil
+	}
+	m := newMatrix(len(a), len(a[0]))
+	for i := range a {
+		for j := range a[i] {
+			m[i][j].Set(&a[i][j])
+		}
+	}
+	return m
+}
+
+func transpose(a [][]big.Int) [][]big.Int {
+	if len(a) == 0 {
+		return nil
+	}
+	m := newMatrix(len(a[0]), len(a))
+	for i := range a {
+		for j := range a[i] {
+			m[j][i].Set(&a[i][j])
+		}
+	}
+	return m
+}
+
+// Compute a basis of the kernel {v : A v = 0} of a matrix over Z/pZ,
+// by reduction to row echelon form. The vectors have entries in [0, p).
+func kernelModP(a [][]big.Int, p *big.Int) [][]big.Int {
+	if len(a) == 0 {
+		return nil
+	}
+	m := copyMatrix(a)
+	rows, cols := len(m), len(m[0])
+	for i := range m {
+		for j := range m[i] {
+			m[i][j].Mod(&m[i][j], p)
+		}
+	}
+	pivotCols := []int{}
+	t := big.NewInt(0)
+	r := 0
+	for c := 0; c < cols && r < rows; c++ {
+		k := r
+		for k < rows && m[k][c].Sign() == 0 {
+			k++
+		}
+		if k == rows {
+			continue
+		}
+		m[r], m[k] = m[k], m[r]
+		inv := new(big.Int).ModInverse(&m[r][c], p)
+		for j := c; j < cols; j++ {
+			m[r][j].Mul(&m[r][j], inv)
+			m[r][j].Mod(&m[r][j], p)
+		}
+		for i := 0; i < rows; i++ {
+			if i == r || m[i][c].Sign() == 0 {
+				continue
+			}
+			f := new(big.Int).Set(&m[i][c])
+			for j := c; j < cols; j++ {
+				t.Mul(f, &m[r][j])
+				m[i][j].Sub(&m[i][j], t)
+				m[i][j].Mod(&m[i][j], p)
+			}
+		}
+		pivotCols = append(pivotCols, c)
+		r++
+	}
+
+	kernel := [][]big.Int{}
+	isPivot := make([]bool, cols)
+	for _, c := range pivotCols {
+		isPivot[c] = true
+	}
+	for free := 0; free < cols; free++ {
+		if isPivot[free] {
+			continue
+		}
+		v := make([]big.Int, cols)
+		v[free].SetInt64(1)
+		for i, c := range pivotCols {
+			v[c].Neg(&m[i][free])
+			v[c].Mod(&v[c], p)
+		}
+		kernel = append(kernel, v)
+	}
+	return kernel
+}
+
+// Compute the Hermite normal form of the lattice spanned by the rows of
+// a, vectors of length n, which must have full rank n. The result is an n by n
+// lower triangular basis h with h[i][i] > 0 and 0 <= h[i][j] < h[j][j]
+// for j < i. If mod is not nil, the lattice must contain mod * Z^n, and
+// all intermediate entries are kept below mod.
+// Cohen, Alg. 2.4.4 and 2.4.8, eliminating from the last column.
+func hnfLower(a [][]big.Int, n int, mod *big.Int) [][]big.Int {
+	active := copyMatrix(a)
+	h := make([][]big.Int, n)
+	g, x, y := new(big.Int), new(big.Int), new(big.Int)
+	u, v, t := new(big.Int), new(big.Int), new(big.Int)
+	for c := n - 1; c >= 0; c-- {
+		// The vectors mod * e_j are only added when their column is
+		// reached, since reducing modulo mod would destroy them.
+		if mod != nil {
+			r := make([]big.Int, n)
+			r[c].Set(mod)
+			active = append(active, r)
+		}
+		var pivot []big.Int
+		rest := [][]big.Int{}
+		for _, r := range active {
+			if r[c].Sign() == 0 {
+				rest = append(rest, r)
+				continue
+			}
+			if pivot == nil {
+				pivot = r
+				continue
+			}
+			// Replace (pivot, r) by (x pivot + y r, u pivot - v r) where
+			// g = x pivot[c] + y r[c], u = r[c]/g and v = pivot[c]/g.
+			g.GCD(x, y, new(big.Int).Abs(&pivot[c]), new(big.Int).Abs(&r[c]))
+			if pivot[c].Sign() < 0 {
+				x.Neg(x)
+			}
+			if r[c].Sign() < 0 {
+				y.Neg(y)
+			}
+			u.Quo(&r[c], g)
+			v.Quo(&pivot[c], g)
+			p2 := make([]big.Int, n)
+			r2 := make([]big.Int, n)
+			for j := 0; j <= c; j++ {
+				p2[j].Mul(x, &pivot[j])
+				p2[j].Add(&p2[j], t.Mul(y, &r[j]))
+				r2[j].Mul(u, &pivot[j])
+				r2[j].Sub(&r2[j], t.Mul(v, &r[j]))
+			}
+			pivot = p2
+			if !isZeroVector(r2) {
+				rest = append(rest, r2)
+			}
+		}
+		if pivot == nil {
+			panic("mathx: lattice is not of full rank")
+		}
+		if pivot[c].Sign() < 0 {
+			for j := range pivot {
+				pivot[j].Neg(&pivot[j])
+			}
+		}
+		if mod != nil {
+			for _, r := range append(rest, pivot) {
+				for j := 0; j < c; j++ {
+					r[j].Mod(&r[j], mod)
+				}
+			}
+		}
+		h[c] = pivot
+		active = rest
+	}
+	for i := 1; i < n; i++ {
+		for j := i - 1; j >= 0; j-- {
+			t.Div(&h[i][j], &h[j][j])
+			if t.Sign() == 0 {
+				continue
+			}
+			for k := 0; k <= j; k++ {
+				h[i][k].Sub(&h[i][k], new(big.Int).Mul(t, &h[j][k]))
+			}
+		}
+	}
+	return h
+}
+
+// Solve x h = v for a lower triangular h with nonzero diagonal, that is,
+// write v in terms of the rows of h. Returns nil if the solution is not
+// integral.
+func solveLower(h [][]big.Int, v []big.Int) []big.Int {
+	n := len(h)
+	x := make([]big.Int, n)
+	s, r := new(big.Int), new(big.Int)
+	for j := n - 1; j >= 0; j-- {
+		s.Set(&v[j])
+		for i := j + 1; i < n; i++ {
+			s.Sub(s, r.Mul(&x[i], &h[i][j]))
+		}
+		x[j].QuoRem(s, &h[j][j], r)
+		if r.Sign() != 0 {
+			return nil
+		}
+	}
+	return x
+}
+
+func isZeroVector(v []big.Int) bool {
+	for i := range v {
+		if v[i].Sign() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Compute the determinant of a square integer matrix with Bareiss'
+// fraction-free elimination.
+// Cohen, Alg. 2.2.6.
+func determinant(a [][]big.Int) *big.Int {
+	n := len(a)
+	if n == 0 {
+		return big.NewInt(1)
+	}
+	m := copyMatrix(a)
+	sign := 1
+	prev := big.NewInt(1)
+	t := new(big.Int)
+	for k := 0; k < n-1; k++ {
+		if m[k][k].Sign() == 0 {
+			i := k + 1
+			for i < n && m[i][k].Sign() == 0 {
+				i++
+			}
+			if i == n {
+				return big.NewInt(0)
+			}
+			m[k], m[i] = m[i], m[k]
+			sign = -sign
+		}
+		for i := k + 1; i < n; i++ {
+			for j := k + 1; j < n; j++ {
+				m[i][j].Mul(&m[i][j], &m[k][k])
+				m[i][j].Sub(&m[i][j], t.Mul(&m[i][k], &m[k][j]))
+				m[i][j].Quo(&m[i][j], prev)
+			}
+		}
+		prev = &m[k][k]
+	}
+	d := new(big.Int).Set(&m[n-1][n-1])
+	if sign < 0 {
+		d.Neg(d)
+	}
+	return d
+}
+
+// Invert a square rational matrix by Gauss-Jordan elimination. Returns
+// nil if the matrix is singular.
+func ratInverse(a [][]big.Rat) [][]big.Rat {
+	n := len(a)
+	m := make([][]big.Rat, n)
+	for i := range m {
+		m[i] = make([]big.Rat, 2*n)
+		for j := 0; j < n; j++ {
+			m[i][j].Set(&a[i][j])
+		}
+		m[i][n+i].SetInt64(1)
+	}
+	t := new(big.Rat)
+	for c := 0; c < n; c++ {
+		k := c
+		for k < n && m[k][c].Sign() == 0 {
+			k++
+		}
+		if k == n {
+			return nil
+		}
+		m[c], m[k] = m[k], m[c]
+		inv := new(big.Rat).Inv(&m[c][c])
+		for j := c; j < 2*n; j++ {
+			m[c][j].Mul(&m[c][j], inv)
+		}
+		for i := 0; i < n; i++ {
+			if i == c || m[i][c].Sign() == 0 {
+				continue
+			}
+			f := new(big.Rat).Set(&m[i][c])
+			for j := c; j < 2*n; j++ {
+				m[i][j].Sub(&m[i][j], t.Mul(f, &m[c][j]))
+			}
+		}
+	}
+	for i := range m {
+		m[i] = m[i][n:]
+	}
+	return m
+}
+
+// Scale rational rows to integer rows over a common denominator.
+func clearDenominators(a [][]big.Rat) ([][]big.Int, *big.Int) {
+	d := big.NewInt(1)
+	g := new(big.Int)
+	for i := range a {
+		for j := range a[i] {
+			q := a[i][j].Denom()
+			g.GCD(nil, nil, d, q)
+			d.Mul(d, q).Quo(d, g)
+		}
+	}
+	m := newMatrix(len(a), len(a[0]))
+	for i := range a {
+		for j := range a[i] {
+			m[i][j].Mul(a[i][j].Num(), d)
+			m[i][j].Quo(&m[i][j], a[i][j].Denom())
+		}
+	}
+	return m, d
+}
+
+// Compute the Smith normal form of a square integer matrix a of full
+// rank: unimodular U and V with U a V diagonal, each diagonal entry
+// positive and dividing the next. Returns the diagonal and V^-1; row i
+// of V^-1 expresses the generator of the i-th cyclic factor of
+// Z^n / (row lattice of a) in terms of the standard basis.
+// Cohen, Alg. 2.4.14, with elementary operations only.
+func smithForm(a [][]big.Int) ([]big.Int, [][]big.Int) {
+	n := len(a)
+	m := copyMatrix(a)
+	vinv := identityMatrix(n)
+	q := new(big.Int)
+	t := new(big.Int)
+	for s := 0; s < n; s++ {
+		for {
+			// Move the smallest nonzero entry of the submatrix to (s, s).
+			pi, pj := -1, -1
+			for i := s; i < n; i++ {
+				for j := s; j < n; j++ {
+					if m[i][j].Sign() != 0 && (pi < 0 || m[i][j].CmpAbs(&m[pi][pj]) < 0) {
+						pi, pj = i, j
+					}
+				}
+			}
+			if pi < 0 {
+				panic("mathx: matrix is singular")
+			}
+			m[s], m[pi] = m[pi], m[s]
+			if pj != s {
+				for i := range m {
+					m[i][s], m[i][pj] = m[i][pj], m[i][s]
+				}
+				vinv[s], vinv[pj] = vinv[pj], vinv[s]
+			}
+			if m[s][s].Sign() < 0 {
+				for j := range m[s] {
+					m[s][j].Neg(&m[s][j])
+				}
+			}
+
+			done := true
+			for i := s + 1; i < n; i++ {
+				if m[i][s].Sign() == 0 {
+					continue
+				}
+				q.Quo(&m[i][s], &m[s][s])
+				for j := s; j < n; j++ {
+					m[i][j].Sub(&m[i][j], t.Mul(q, &m[s][j]))
+				}
+				if m[i][s].Sign() != 0 {
+					done = false
+				}
+			}
+			for j := s + 1; j < n; j++ {
+				if m[s][j].Sign() == 0 {
+					continue
+				}
+				q.Quo(&m[s][j], &m[s][s])
+				for i := s; i < n; i++ {
+					m[i][j].Sub(&m[i][j], t.Mul(q, &m[i][s]))
+				}
+				for k := range vinv[s] {
+					vinv[s][k].Add(&vinv[s][k], t.Mul(q, &vinv[j][k]))
+				}
+				if m[s][j].Sign() != 0 {
+					done = false
+				}
+			}
+			if !done {
+				continue
+			}
+			// The pivot must divide the rest of the submatrix; if not,
+			// add the offending row and start again.
+			for i := s + 1; i < n && done; i++ {
+				for j := s + 1; j < n; j++ {
+					if t.Mod(&m[i][j], &m[s][s]).Sign() != 0 {
+						for k := s; k < n; k++ {
+							m[s][k].Add(&m[s][k], &m[i][k])
+						}
+						done = false
+						break
+					}
+				}
+			}
+			if done {
+				break
+			}
+		}
+	}
+	d := make([]big.Int, n)
+	for i := range d {
+		d[i].Set(&m[i][i])
+	}
+	return d, vinv
+}
@@ -15,8 +15,12 @@
 
 package mathx
 
+import "math/big"
+
 type NumberField struct {
 	polynomial *IntPolynomial
+	// Computed on demand by ringOfIntegers.
+	maximalOrder *order
 }
 
 func MakeNumberField(poly *IntPolynomial) *NumberField {
@@ -25,15 +29,41 @@ func MakeNumberField(poly *IntPolynomial) *NumberField {
 	return k
 }
 
+func (k *NumberField) Polynomial() *IntPolynomial {
+	return k.polynomial.Copy()
+}
+
 func (k *NumberField) Degree() int {
 	return len(k.polynomial.coeffs) - 1
 }
 
+// Compute the class number of a quadratic field, or -1 if it is not
+// known; ClassNumberBig tells why.
 func (k *NumberField) ClassNumber() int {
-	if k.Degree() == 2 {
-		if k.polynomial.Discriminant().Sign() < 0 {
-			return classNumberImagQuadSlow(k)
-		}
+	h, err := k.ClassNumberBig()
+	if err != nil || !h.IsInt64() {
+		return -1
+	}
+	return int(h.Int64())
+}
+
+// Compute the class number of a quadratic field. Imaginary fields
+// enumerate reduced forms or use baby-step giant-step, and real fields
+// count cycles of reduced forms, which gives ErrClassGroupTooLarge for
+// discriminants of classNumberBSGSBound or more. The error is ErrNotQuadratic for other
+// degrees, or whatever computing the discriminant or the class number
+// failed with.
+func (k *NumberField) ClassNumberBig() (*big.Int, error) {
+	if k.Degree() != 2 {
+		return nil, ErrNotQuadratic
+	}
+	o, err := k.ringOfIntegers()
+	if err != nil {
+		return nil, err
+	}
+	D := o.discriminant()
+	if D.Sign() < 0 {
+		return ClassNumberImagQuad(D)
 	}
-	return -1
+	return ClassNumberRealQuad(D)
 }
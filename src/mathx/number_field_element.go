@@ -0,0 +1,460 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+)
+
+var ErrNotInvertible = errors.New("mathx: element is not invertible")
+
+// An element of a number field K = Q(a), stored by its rational
+// coordinates with respect to the power basis 1, a, ..., a^(n-1), where
+// a is the root of the defining polynomial.
+type NumberFieldElement struct {
+	field  *NumberField
+	coords []big.Rat
+}
+
+// Create the element c_0 + c_1 a + ... of k. Coordinates beyond the
+// degree are reduced with the defining polynomial.
+func (k *NumberField) NewElement(coords []*big.Rat) *NumberFieldElement {
+	c := make([]big.Rat, len(coords))
+	for i := range coords {
+		c[i].Set(coords[i])
+	}
+	return k.newElement(c)
+}
+
+func (k *NumberField) NewElement64(coords ...int64) *NumberFieldElement {
+	c := make([]big.Rat, len(coords))
+	for i := range coords {
+		c[i].SetInt64(coords[i])
+	}
+	return k.newElement(c)
+}
+
+// Takes ownership of c.
+func (k *NumberField) newElement(c []big.Rat) *NumberFieldElement {
+	x := &NumberFieldElement{field: k}
+	x.coords = ratPolyMod(c, k.polynomial)
+	return x
+}
+
+func (k *NumberField) Zero() *NumberFieldElement {
+	return k.NewElement64()
+}
+
+func (k *NumberField) One() *NumberFieldElement {
+	return k.NewElement64(1)
+}
+
+// The root a of the defining polynomial.
+func (k *NumberField) Generator() *NumberFieldElement {
+	return k.NewElement64(0, 1)
+}
+
+func (x *NumberFieldElement) Field() *NumberField {
+	return x.field
+}
+
+// Return a copy of the coordinates, always n of them.
+func (x *NumberFieldElement) Coords() []*big.Rat {
+	c := make([]*big.Rat, x.field.Degree())
+	for i := range c {
+		c[i] = new(big.Rat)
+		if i < len(x.coords) {
+			c[i].Set(&x.coords[i])
+		}
+	}
+	return c
+}
+
+func (x *NumberFieldElement) IsZero() bool {
+	return len(x.coords) == 0
+}
+
+func (x *NumberFieldElement) Equal(y *NumberFieldElement) bool {
+	if len(x.coords) != len(y.coords) {
+		return false
+	}
+	for i := range x.coords {
+		if x.coords[i].Cmp(&y.coords[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (x *NumberFieldElement) Add(y *NumberFieldElement) *NumberFieldElement {
+	n := len(x.coords)
+	if len(y.coords) > n {
+		n = len(y.coords)
+	}
+	c := make([]big.Rat, n)
+	for i := range c {
+		if i < len(x.coords) {
+			c[i].Add(&c[i], &x.coords[i])
+		}
+		if i < len(y.coords) {
+			c[i].Add(&c[i], &y.coords[i])
+		}
+	}
+	return x.field.newElement(c)
+}
+
+func (x *NumberFieldElement) Neg() *NumberFieldElement {
+	c := make([]big.Rat, len(x.coords))
+	for i := range c {
+		c[i].Neg(&x.coords[i])
+	}
+	return x.field.newElement(c)
+}
+
+func (x *NumberFieldElement) Sub(y *NumberFieldElement) *NumberFieldElement {
+	return x.Add(y.Neg())
+}
+
+func (x *NumberFieldElement) Mul(y *NumberFieldElement) *NumberFieldElement {
+	return x.field.newElement(ratPolyMul(x.coords, y.coords))
+}
+
+// Multiply by a rational number.
+func (x *NumberFieldElement) MulRat(r *big.Rat) *NumberFieldElement {
+	c := make([]big.Rat, len(x.coords))
+	for i := range c {
+		c[i].Mul(&x.coords[i], r)
+	}
+	return x.field.newElement(c)
+}
+
+// Compute the inverse of x by the extended Euclidean algorithm in
+// Q[t]: if s x + t f = 1 then s is the inverse of x modulo f. Fails if
+// x is zero or, when the defining polynomial is reducible, a zero
+// divisor.
+func (x *NumberFieldElement) Inverse() (*NumberFieldElement, error) {
+	if x.IsZero() {
+		return nil, ErrDivisionByZero
+	}
+	f := make([]big.Rat, len(x.field.polynomial.coeffs))
+	for i := range f {
+		f[i].SetInt(&x.field.polynomial.coeffs[i])
+	}
+	g, s := ratPolyExtendedGCD(x.coords, f)
+	if len(g) != 1 {
+		return nil, ErrNotInvertible
+	}
+	return x.field.newElement(s), nil
+}
+
+func (x *NumberFieldElement) Div(y *NumberFieldElement) (*NumberFieldElement, error) {
+	z, err := y.Inverse()
+	if err != nil {
+		return nil, err
+	}
+	return x.Mul(z), nil
+}
+
+// Compute x^e by repeated squaring; negative e requires x invertible.
+func (x *NumberFieldElement) Pow(e int) (*NumberFieldElement, error) {
+	s := x
+	if e < 0 {
+		var err error
+		s, err = x.Inverse()
+		if err != nil {
+			return nil, err
+		}
+		e = -e
+	}
+	r := x.field.One()
+	for ; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			r = r.Mul(s)
+		}
+		if e > 1 {
+			s = s.Mul(s)
+		}
+	}
+	return r, nil
+}
+
+// The matrix of multiplication by x on the power basis: column j holds
+// the coordinates of x a^j.
+func (x *NumberFieldElement) matrix() [][]big.Rat {
+	n := x.field.Degree()
+	m := make([][]big.Rat, n)
+	for i := range m {
+		m[i] = make([]big.Rat, n)
+	}
+	y := x
+	a := x.field.Generator()
+	for j := 0; j < n; j++ {
+		for i := range y.coords {
+			m[i][j].Set(&y.coords[i])
+		}
+		y = y.Mul(a)
+	}
+	return m
+}
+
+// Compute the characteristic polynomial det(X - M_x) of multiplication
+// by x, with the Faddeev-LeVerrier algorithm. The result is monic with
+// rational coefficients, constant term first.
+// Cohen, Alg. 2.2.7.
+func (x *NumberFieldElement) charPoly() []big.Rat {
+	a := x.matrix()
+	n := len(a)
+	c := make([]big.Rat, n+1)
+	c[n].SetInt64(1)
+	m := make([][]big.Rat, n)
+	for i := range m {
+		m[i] = make([]big.Rat, n)
+	}
+	t := new(big.Rat)
+	for k := 1; k <= n; k++ {
+		// m = a m + c[n-k+1] I, then c[n-k] = -tr(a m) / k.
+		am := make([][]big.Rat, n)
+		for i := range am {
+			am[i] = make([]big.Rat, n)
+			for j := range am[i] {
+				for l := 0; l < n; l++ {
+					am[i][j].Add(&am[i][j], t.Mul(&a[i][l], &m[l][j]))
+				}
+			}
+			am[i][i].Add(&am[i][i], &c[n-k+1])
+		}
+		m = am
+		tr := new(big.Rat)
+		for i := 0; i < n; i++ {
+			for l := 0; l < n; l++ {
+				tr.Add(tr, t.Mul(&a[i][l], &m[l][i]))
+			}
+		}
+		c[n-k].Quo(tr, big.NewRat(-int64(k), 1))
+	}
+	return c
+}
+
+// Compute the characteristic polynomial of x, scaled to a primitive
+// integer polynomial with positive leading coefficient.
+func (x *NumberFieldElement) CharPoly() *IntPolynomial {
+	return ratPolyToPrimitive(x.charPoly())
+}
+
+// Compute the minimal polynomial of x over Q, scaled to a primitive
+// integer polynomial with positive leading coefficient. The
+// characteristic polynomial is a power of it.
+func (x *NumberFieldElement) MinPoly() *IntPolynomial {
+	parts := x.CharPoly().SquareFreeDecomposition()
+	for _, p := range parts {
+		if p != nil && p.Degree() > 0 {
+			return p
+		}
+	}
+	return NewIntPolynomial64(0, 1)
+}
+
+// Compute the norm of x from K to Q, the determinant of multiplication
+// by x.
+func (x *NumberFieldElement) Norm() *big.Rat {
+	c := x.charPoly()
+	r := new(big.Rat).Set(&c[0])
+	if (len(c)-1)&1 == 1 {
+		r.Neg(r)
+	}
+	return r
+}
+
+// Compute the trace of x from K to Q, the trace of multiplication by x.
+func (x *NumberFieldElement) Trace() *big.Rat {
+	m := x.matrix()
+	r := new(big.Rat)
+	for i := range m {
+		r.Add(r, &m[i][i])
+	}
+	return r
+}
+
+// Tell if x lies in the ring of integers, that is, if its minimal
+// polynomial is monic.
+func (x *NumberFieldElement) IsIntegral() bool {
+	return x.MinPoly().LeadingCoeff().Cmp(intOne) == 0
+}
+
+func (x *NumberFieldElement) String() string {
+	if x.IsZero() {
+		return "0"
+	}
+	s := ""
+	for i := len(x.coords) - 1; i >= 0; i-- {
+		c := &x.coords[i]
+		if c.Sign() == 0 {
+			continue
+		}
+		abs := new(big.Rat).Abs(c)
+		if s == "" {
+			if c.Sign() < 0 {
+				s = "-"
+			}
+		} else if c.Sign() < 0 {
+			s += " - "
+		} else {
+			s += " + "
+		}
+		switch {
+		case i == 0:
+			s += abs.RatString()
+		case abs.Cmp(big.NewRat(1, 1)) == 0:
+			s += astring(i)
+		default:
+			s += abs.RatString() + "*" + astring(i)
+		}
+	}
+	return s
+}
+
+func astring(i int) string {
+	if i == 1 {
+		return "a"
+	}
+	return "a^" + strconv.Itoa(i)
+}
+
+// Polynomials with rational coefficients, constant term first, with no
+// trailing zeros.
+
+func ratPolyTrim(a []big.Rat) []big.Rat {
+	n := len(a)
+	for n > 0 && a[n-1].Sign() == 0 {
+		n--
+	}
+	return a[:n]
+}
+
+func ratPolyMul(a, b []big.Rat) []big.Rat {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+	c := make([]big.Rat, len(a)+len(b)-1)
+	t := new(big.Rat)
+	for i := range a {
+		for j := range b {
+			c[i+j].Add(&c[i+j], t.Mul(&a[i], &b[j]))
+		}
+	}
+	return ratPolyTrim(c)
+}
+
+// Divide a by b, which must be nonzero, returning quotient and
+// remainder. a is not modified.
+func ratPolyDivMod(a, b []big.Rat) ([]big.Rat, []big.Rat) {
+	r := make([]big.Rat, len(a))
+	for i := range a {
+		r[i].Set(&a[i])
+	}
+	r = ratPolyTrim(r)
+	m := len(b) - 1
+	if len(r)-1 < m {
+		return nil, r
+	}
+	q := make([]big.Rat, len(r)-m)
+	t := new(big.Rat)
+	for k := len(r) - 1; k >= m; k-- {
+		q[k-m].Quo(&r[k], &b[m])
+		if q[k-m].Sign() == 0 {
+			continue
+		}
+		for j := 0; j <= m; j++ {
+			r[k-m+j].Sub(&r[k-m+j], t.Mul(&q[k-m], &b[j]))
+		}
+	}
+	return q, ratPolyTrim(r[:m])
+}
+
+// Reduce a modulo the integer polynomial f.
+func ratPolyMod(a []big.Rat, f *IntPolynomial) []big.Rat {
+	a = ratPolyTrim(a)
+	if len(a) < len(f.coeffs) {
+		return a
+	}
+	b := make([]big.Rat, len(f.coeffs))
+	for i := range b {
+		b[i].SetInt(&f.coeffs[i])
+	}
+	_, r := ratPolyDivMod(a, b)
+	return r
+}
+
+// Compute the monic gcd g of a and b, and s with s a = g modulo b.
+func ratPolyExtendedGCD(a, b []big.Rat) ([]big.Rat, []big.Rat) {
+	r0, r1 := ratPolyTrim(a), ratPolyTrim(b)
+	s0, s1 := []big.Rat{*big.NewRat(1, 1)}, []big.Rat(nil)
+	for len(r1) > 0 {
+		q, r := ratPolyDivMod(r0, r1)
+		s := ratPolySub(s0, ratPolyMul(q, s1))
+		r0, r1 = r1, r
+		s0, s1 = s1, s
+	}
+	if len(r0) == 0 {
+		return r0, s0
+	}
+	lc := new(big.Rat).Inv(&r0[len(r0)-1])
+	for i := range r0 {
+		r0[i].Mul(&r0[i], lc)
+	}
+	for i := range s0 {
+		s0[i].Mul(&s0[i], lc)
+	}
+	return r0, s0
+}
+
+func ratPolySub(a, b []big.Rat) []big.Rat {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	c := make([]big.Rat, n)
+	for i := range c {
+		if i < len(a) {
+			c[i].Set(&a[i])
+		}
+		if i < len(b) {
+			c[i].Sub(&c[i], &b[i])
+		}
+	}
+	return ratPolyTrim(c)
+}
+
+// Scale a nonzero rational polynomial to a primitive integer polynomial
+// with positive leading coefficient.
+func ratPolyToPrimitive(a []big.Rat) *IntPolynomial {
+	l := big.NewInt(1)
+	g := new(big.Int)
+	for i := range a {
+		d := a[i].Denom()
+		g.GCD(nil, nil, l, d)
+		l.Mul(l, d).Quo(l, g)
+	}
+	p := new(IntPolynomial)
+	p.coeffs = make([]big.Int, len(a))
+	for i := range a {
+		p.coeffs[i].Mul(a[i].Num(), l)
+		p.coeffs[i].Quo(&p.coeffs[i], a[i].Denom())
+	}
+	return p.trim().PrimitivePart().normalizeSign()
+}
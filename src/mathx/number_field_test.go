@@ -0,0 +1,377 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func ratMatrixString(a [][]*big.Rat) string {
+	rows := []string{}
+	for _, r := range a {
+		s := []string{}
+		for _, x := range r {
+			s = append(s, x.RatString())
+		}
+		rows = append(rows, "["+strings.Join(s, " ")+"]")
+	}
+	return "[" + strings.Join(rows, " ") + "]"
+}
+
+var fieldDiscriminantTestCases = []struct {
+	polyString   string
+	discriminant string
+	index        string
+}{
+	{"x^2 + 3", "-3", "2"},
+	{"x^2 + 4", "-4", "2"},
+	{"x^2 - 5", "5", "2"},
+	{"x^2 + 5", "-20", "1"},
+	{"x^2 - 12", "12", "2"},
+	{"2*x^2 - 1", "8", "1"},
+	{"3*x^2 + 1", "-3", "2"},
+	{"x^3 - 2", "-108", "1"},
+	{"x^3 - 19", "-1083", "3"},
+	{"x^3 + x^2 - 2*x + 8", "-503", "2"},
+	{"x^4 + 1", "256", "1"},
+	{"x^4 + x^3 + x^2 + x + 1", "125", "1"},
+	{"x^4 - 10*x^2 + 1", "2304", "8"},
+	{"x^5 - 2", "50000", "1"},
+	{"x^6 + x^3 + 1", "-19683", "1"},
+}
+
+func TestFieldDiscriminant(t *testing.T) {
+	for _, testCase := range fieldDiscriminantTestCases {
+		k := MakeNumberField(ParseIntPoly(testCase.polyString))
+		if d := k.Discriminant(); d == nil || d.String() != testCase.discriminant {
+			t.Errorf("%s: expected discriminant %s, got %v", testCase.polyString, testCase.discriminant, d)
+		}
+		index, err := k.Index()
+		if err != nil || index.String() != testCase.index {
+			t.Errorf("%s: expected index %s, got %v (%v)", testCase.polyString, testCase.index, index, err)
+		}
+	}
+}
+
+func TestIntegralBasis(t *testing.T) {
+	k := MakeNumberField(ParseIntPoly("x^3 + x^2 - 2*x + 8"))
+	basis, err := k.IntegralBasis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[[1 0 0] [0 1 0] [0 1/2 1/2]]"
+	if got := ratMatrixString(basis); got != expected {
+		t.Errorf("expected integral basis %s, got %s", expected, got)
+	}
+
+	k = MakeNumberField(ParseIntPoly("2*x^2 - 1"))
+	basis, err = k.IntegralBasis()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = "[[1 0] [0 2]]"
+	if got := ratMatrixString(basis); got != expected {
+		t.Errorf("expected integral basis %s, got %s", expected, got)
+	}
+
+	k = MakeNumberField(ParseIntPoly("x^4 - 2*x^2 + 1"))
+	if _, err := k.IntegralBasis(); err != ErrNotSquareFree {
+		t.Errorf("expected ErrNotSquareFree, got %v", err)
+	}
+}
+
+func TestFactorBig(t *testing.T) {
+	n, _ := new(big.Int).SetString("-1208925819614629174706176", 10) // -2^80
+	f, err := factorBig(n)
+	if err != nil || len(f) != 1 || f[0].prime.Int64() != 2 || f[0].exponent != 80 {
+		t.Errorf("bad factorization of %s: %v", n, f)
+	}
+	// (10^9 + 7)^2 * (10^9 + 9) * 1000003
+	p := big.NewInt(1000000007)
+	q := big.NewInt(1000000009)
+	n = new(big.Int).Mul(p, p)
+	n.Mul(n, q).Mul(n, big.NewInt(1000003))
+	f, err = factorBig(n)
+	if err != nil || len(f) != 3 ||
+		f[0].prime.Int64() != 1000003 || f[0].exponent != 1 ||
+		f[1].prime.Cmp(p) != 0 || f[1].exponent != 2 ||
+		f[2].prime.Cmp(q) != 0 || f[2].exponent != 1 {
+		t.Errorf("bad factorization of %s: %v", n, f)
+	}
+}
+
+func TestNumberFieldElement(t *testing.T) {
+	k := MakeNumberField(ParseIntPoly("x^2 + 3"))
+	w := k.NewElement([]*big.Rat{big.NewRat(1, 2), big.NewRat(1, 2)})
+	if w.String() != "1/2*a + 1/2" {
+		t.Errorf("bad string %s", w)
+	}
+	if n := w.Norm(); n.RatString() != "1" {
+		t.Errorf("expected norm 1, got %s", n.RatString())
+	}
+	if tr := w.Trace(); tr.RatString() != "1" {
+		t.Errorf("expected trace 1, got %s", tr.RatString())
+	}
+	if m := w.MinPoly().String(); m != "x^2 - 1*x + 1" {
+		t.Errorf("expected minimal polynomial x^2 - 1*x + 1, got %s", m)
+	}
+	if !w.IsIntegral() || k.Generator().MulRat(big.NewRat(1, 2)).IsIntegral() {
+		t.Errorf("bad integrality test")
+	}
+	// w is a primitive sixth root of unity.
+	if w6, _ := w.Pow(6); !w6.Equal(k.One()) {
+		t.Errorf("expected w^6 = 1, got %s", w6)
+	}
+	if w3, _ := w.Pow(3); !w3.Equal(k.NewElement64(-1)) {
+		t.Errorf("expected w^3 = -1, got %s", w3)
+	}
+
+	k = MakeNumberField(ParseIntPoly("2*x^3 - x + 5"))
+	a := k.Generator()
+	x := k.NewElement64(3, -1, 4)
+	y, err := x.Inverse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !x.Mul(y).Equal(k.One()) {
+		t.Errorf("%s * %s != 1", x, y)
+	}
+	if z, _ := x.Div(x); !z.Equal(k.One()) {
+		t.Errorf("%s / %s != 1", x, x)
+	}
+	if _, err := k.Zero().Inverse(); err != ErrDivisionByZero {
+		t.Errorf("expected ErrDivisionByZero, got %v", err)
+	}
+	// a is a root of its defining polynomial.
+	if !a.Mul(a).Mul(a).MulRat(big.NewRat(2, 1)).Sub(a).Add(k.NewElement64(5)).IsZero() {
+		t.Errorf("generator is not a root")
+	}
+	if c := a.CharPoly().String(); c != "2*x^3 - 1*x + 5" {
+		t.Errorf("expected characteristic polynomial 2*x^3 - 1*x + 5, got %s", c)
+	}
+	if n := a.Norm(); n.RatString() != "-5/2" {
+		t.Errorf("expected norm -5/2, got %s", n.RatString())
+	}
+	// The norm is multiplicative.
+	nxy := x.Mul(a).Norm()
+	if nxy.Cmp(new(big.Rat).Mul(x.Norm(), a.Norm())) != 0 {
+		t.Errorf("norm is not multiplicative")
+	}
+
+	// 1 + a is a unit of norm -1 in Q(sqrt(2)), and a - 1 one of
+	// norm 1 in Q(cbrt(2)).
+	k = MakeNumberField(ParseIntPoly("x^2 - 2"))
+	if n := k.NewElement64(1, 1).Norm(); n.RatString() != "-1" {
+		t.Errorf("expected norm -1, got %s", n.RatString())
+	}
+	k = MakeNumberField(ParseIntPoly("x^3 - 2"))
+	u := k.NewElement64(-1, 1)
+	if n := u.Norm(); n.RatString() != "1" {
+		t.Errorf("expected norm 1, got %s", n.RatString())
+	}
+	if v, _ := u.Inverse(); v.String() != "a^2 + a + 1" {
+		t.Errorf("expected inverse a^2 + a + 1, got %s", v)
+	}
+	if m := k.NewElement64(0, 0, 1).MinPoly().String(); m != "x^3 - 4" {
+		t.Errorf("expected minimal polynomial x^3 - 4, got %s", m)
+	}
+	if m := k.NewElement64(7).MinPoly().String(); m != "x - 7" {
+		t.Errorf("expected minimal polynomial x - 7, got %s", m)
+	}
+}
+
+var primeDecompositionTestCases = []struct {
+	polyString string
+	p          int64
+	// Ramification index and residue degree of each prime, sorted.
+	ef [][2]int
+}{
+	{"x^2 + 1", 2, [][2]int{{2, 1}}},
+	{"x^2 + 1", 3, [][2]int{{1, 2}}},
+	{"x^2 + 1", 5, [][2]int{{1, 1}, {1, 1}}},
+	{"x^2 + 3", 2, [][2]int{{1, 2}}},
+	{"x^2 + 3", 3, [][2]int{{2, 1}}},
+	{"x^2 + 3", 7, [][2]int{{1, 1}, {1, 1}}},
+	{"x^2 - 5", 11, [][2]int{{1, 1}, {1, 1}}},
+	{"x^2 - 5", 2, [][2]int{{1, 2}}},
+	{"x^3 - 2", 2, [][2]int{{3, 1}}},
+	{"x^3 - 2", 5, [][2]int{{1, 1}, {1, 2}}},
+	{"x^3 - 2", 31, [][2]int{{1, 1}, {1, 1}, {1, 1}}},
+	{"x^3 + x^2 - 2*x + 8", 2, [][2]int{{1, 1}, {1, 1}, {1, 1}}},
+	{"x^3 + x^2 - 2*x + 8", 503, [][2]int{{1, 1}, {2, 1}}},
+	{"x^3 - 19", 3, [][2]int{{1, 1}, {2, 1}}},
+	{"x^3 - 19", 19, [][2]int{{3, 1}}},
+	{"x^4 - 10*x^2 + 1", 3, [][2]int{{2, 2}}},
+	{"x^4 - 10*x^2 + 1", 2, [][2]int{{4, 1}}},
+	{"x^4 - 10*x^2 + 1", 23, [][2]int{{1, 1}, {1, 1}, {1, 1}, {1, 1}}},
+	{"x^4 + x^3 + x^2 + x + 1", 11, [][2]int{{1, 1}, {1, 1}, {1, 1}, {1, 1}}},
+	{"x^4 + x^3 + x^2 + x + 1", 2, [][2]int{{1, 4}}},
+	{"x^4 + x^3 + x^2 + x + 1", 5, [][2]int{{4, 1}}},
+	{"2*x^2 - 1", 2, [][2]int{{2, 1}}},
+	{"2*x^2 - 1", 7, [][2]int{{1, 1}, {1, 1}}},
+}
+
+func TestPrimeDecomposition(t *testing.T) {
+	for _, testCase := range primeDecompositionTestCases {
+		k := MakeNumberField(ParseIntPoly(testCase.polyString))
+		p := big.NewInt(testCase.p)
+		ideals, err := k.PrimeDecomposition(p)
+		if err != nil {
+			t.Errorf("%s, p = %d: %v", testCase.polyString, testCase.p, err)
+			continue
+		}
+		ef := [][2]int{}
+		for _, P := range ideals {
+			ef = append(ef, [2]int{P.RamificationIndex(), P.ResidueDegree()})
+		}
+		sort.Slice(ef, func(i, j int) bool {
+			return ef[i][0] < ef[j][0] || ef[i][0] == ef[j][0] && ef[i][1] < ef[j][1]
+		})
+		if fmt.Sprint(ef) != fmt.Sprint(testCase.ef) {
+			t.Errorf("%s, p = %d: expected (e, f) %v, got %v", testCase.polyString, testCase.p, testCase.ef, ef)
+		}
+		// Check that P = (p, pi) has norm p^f: p^f exactly divides
+		// N(pi), since pi is not in P^2 or the other primes.
+		for _, P := range ideals {
+			_, pi := P.Generators()
+			pf := P.Norm()
+			n := pi.Norm()
+			if !n.IsInt() {
+				t.Errorf("%s: generator %s is not integral", testCase.polyString, pi)
+				continue
+			}
+			q, r := new(big.Int).QuoRem(n.Num(), pf, new(big.Int))
+			if r.Sign() != 0 || (new(big.Int).Mod(q, p).Sign() == 0 && len(ideals) > 1) {
+				t.Errorf("%s: bad generator %s for prime above %d, norm %s", testCase.polyString, pi, testCase.p, n.RatString())
+			}
+		}
+	}
+	k := MakeNumberField(ParseIntPoly("x^2 + 1"))
+	if _, err := k.PrimeDecomposition(big.NewInt(15)); err != ErrNotPrime {
+		t.Errorf("expected ErrNotPrime, got %v", err)
+	}
+	if r, _ := k.IsRamified(big.NewInt(2)); !r {
+		t.Errorf("2 should ramify in Q(i)")
+	}
+	if r, _ := k.IsRamified(big.NewInt(3)); r {
+		t.Errorf("3 should not ramify in Q(i)")
+	}
+	// No element of (2, 1 + i) has norm exactly divisible by 2^3.
+	o, _ := k.ringOfIntegers()
+	two := big.NewInt(2)
+	for _, basis := range o.splitRadical(o.radical(two), two) {
+		if _, err := o.uniformizer(basis, two, 3, rand.New(rand.NewSource(1))); err != ErrNoUniformizer {
+			t.Errorf("expected ErrNoUniformizer, got %v", err)
+		}
+	}
+}
+
+func TestIdeals(t *testing.T) {
+	k := MakeNumberField(ParseIntPoly("x^2 + 5"))
+	a := k.Generator()
+	two, _ := k.Ideal(k.NewElement64(2))
+	three, _ := k.Ideal(k.NewElement64(3))
+	six, _ := k.Ideal(k.NewElement64(6))
+	one, _ := k.UnitIdeal()
+	p2, _ := k.Ideal(k.NewElement64(2), k.NewElement64(1, 1))
+	p3, _ := k.Ideal(k.NewElement64(3), k.NewElement64(1, 1))
+	q3, _ := k.Ideal(k.NewElement64(3), k.NewElement64(1, -1))
+
+	if !two.Add(three).Equal(one) {
+		t.Errorf("(2) + (3) = %s", two.Add(three))
+	}
+	if !two.Intersect(three).Equal(six) || !two.Mul(three).Equal(six) {
+		t.Errorf("(2) * (3) = %s, (2) n (3) = %s", two.Mul(three), two.Intersect(three))
+	}
+	if !p2.Mul(p2).Equal(two) {
+		t.Errorf("P2^2 = %s", p2.Mul(p2))
+	}
+	if !p3.Intersect(q3).Equal(three) || !p3.Mul(q3).Equal(three) {
+		t.Errorf("P3 Q3 = %s", p3.Mul(q3))
+	}
+	if n := p3.Norm(); n.RatString() != "3" {
+		t.Errorf("expected norm 3, got %s", n.RatString())
+	}
+	if !p2.Mul(p2.Inverse()).Equal(one) || !p2.Inverse().Equal(p2.Mul(two.Inverse())) {
+		t.Errorf("bad inverse %s of %s", p2.Inverse(), p2)
+	}
+	if !p2.Pow(-2).Mul(two).Equal(one) {
+		t.Errorf("bad power %s", p2.Pow(-2))
+	}
+	if !p2.Contains(a.Add(k.One())) || p2.Contains(a) || !p2.Inverse().Contains(k.NewElement([]*big.Rat{big.NewRat(1, 2), big.NewRat(1, 2)})) {
+		t.Errorf("bad membership test")
+	}
+	if ok, _, err := p2.IsPrincipal(); ok || err != nil {
+		t.Errorf("P2 should not be principal")
+	}
+	if ok, g, err := p2.Mul(p3).IsPrincipal(); !ok || err != nil || g.Norm().RatString() != "6" {
+		t.Errorf("P2 P3 should be principal, got %v %s %v", ok, g, err)
+	}
+	if ok, g, _ := p2.Pow(-2).IsPrincipal(); !ok || g.Norm().RatString() != "1/4" {
+		t.Errorf("P2^-2 should be principal, got %v %s", ok, g)
+	}
+
+	factors, err := six.Mul(p2.Inverse()).Factor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := ""
+	for _, f := range factors {
+		got += fmt.Sprintf("%s^%d ", f.Prime.Ideal(), f.Exponent)
+	}
+	if expected := fmt.Sprintf("%s^1 %s^1 %s^1 ", p2, p3, q3); got != expected && got != fmt.Sprintf("%s^1 %s^1 %s^1 ", p2, q3, p3) {
+		t.Errorf("expected factorization %s, got %s", expected, got)
+	}
+	half, _ := k.Ideal(k.NewElement([]*big.Rat{big.NewRat(1, 2)}))
+	factors, _ = half.Factor()
+	if len(factors) != 1 || factors[0].Exponent != -2 || !factors[0].Prime.Ideal().Equal(p2) {
+		t.Errorf("bad factorization of (1/2): %v", factors)
+	}
+
+	// Dedekind's field, where 2 is a common index divisor.
+	k = MakeNumberField(ParseIntPoly("x^3 + x^2 - 2*x + 8"))
+	two, _ = k.Ideal(k.NewElement64(2))
+	factors, _ = two.Factor()
+	product, _ := k.UnitIdeal()
+	for _, f := range factors {
+		if f.Exponent != 1 {
+			t.Errorf("2 should be unramified, got %v", factors)
+		}
+		product = product.Mul(f.Prime.Ideal().Pow(f.Exponent))
+	}
+	if len(factors) != 3 || !product.Equal(two) {
+		t.Errorf("bad factorization of (2): %v", factors)
+	}
+
+	// Q(cbrt(2)) has class number 1.
+	k = MakeNumberField(ParseIntPoly("x^3 - 2"))
+	ideals, _ := k.PrimeDecomposition(big.NewInt(5))
+	for _, P := range ideals {
+		ok, g, err := P.Ideal().IsPrincipal()
+		if !ok || err != nil {
+			t.Errorf("%s should be principal: %v", P, err)
+			continue
+		}
+		if I, _ := k.Ideal(g); !I.Equal(P.Ideal()) {
+			t.Errorf("%s does not generate %s", g, P)
+		}
+	}
+}
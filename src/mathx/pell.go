@@ -0,0 +1,234 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"errors"
+	"math/big"
+)
+
+var ErrInvalidPellEquation = errors.New("mathx: Pell equation needs D > 0 not a square and N != 0")
+var ErrPellUnitFailed = errors.New("mathx: fundamental unit does not solve the Pell equation")
+
+// A solution x + y sqrt(D) of x^2 - D y^2 = N.
+type PellSolution struct {
+	X, Y *big.Int
+}
+
+// Compute the least solution x, y > 0 of x^2 - D y^2 = 1, from the
+// fundamental unit of the order of discriminant 4D, squared if its norm
+// is -1.
+func PellFundamentalSolution(D *big.Int) (*big.Int, *big.Int, error) {
+	if D.Sign() <= 0 || IsSquare(D) {
+		return nil, nil, ErrInvalidPellEquation
+	}
+	u, y := fundamentalUnit(new(big.Int).Lsh(D, 2))
+	x := u.Rsh(u, 1)
+	if x.Sign() <= 0 || y.Sign() <= 0 || new(big.Int).Abs(pellNorm(x, y, D)).Cmp(intOne) != 0 {
+		return nil, nil, ErrPellUnitFailed
+	}
+	if pellNorm(x, y, D).Sign() < 0 {
+		x, y = pellMul(x, y, x, y, D)
+	}
+	return x, y, nil
+}
+
+// Compute the fundamental solutions of x^2 - D y^2 = N, one for each
+// class of solutions, so that every solution is +-(x + y sqrt(D)) e^k for
+// one of them and e the least solution of the equation with N = 1. Each
+// has x >= 0 and the least |y| in its class. Uses the LMM algorithm:
+// for each f^2 | N and each z with z^2 = D mod |m|, m = N / f^2, the
+// continued fraction of (z + sqrt(D)) / |m| reaches a complete quotient
+// with denominator +-1 exactly when the class of z contains primitive
+// solutions of x^2 - D y^2 = m. The running time is linear in |N|.
+// Matthews, The Diophantine equation x^2 - Dy^2 = N, 2000.
+func SolvePell(D, N *big.Int) ([]PellSolution, error) {
+	t, u, err := PellFundamentalSolution(D)
+	if err != nil {
+		return nil, err
+	}
+	if N.Sign() == 0 {
+		return nil, ErrInvalidPellEquation
+	}
+	aN := new(big.Int).Abs(N)
+	seen := map[string]bool{}
+	solutions := []PellSolution{}
+	m, r, f2, tmp := new(big.Int), new(big.Int), new(big.Int), new(big.Int)
+	for f := big.NewInt(1); f2.Mul(f, f).Cmp(aN) <= 0; f.Add(f, intOne) {
+		if m.QuoRem(N, f2, r); r.Sign() != 0 {
+			continue
+		}
+		am := new(big.Int).Abs(m)
+		// z runs over (-|m| / 2, |m| / 2].
+		z := new(big.Int).Rsh(am, 1)
+		z.Sub(z, am).Add(z, intOne)
+		for ; tmp.Lsh(z, 1).Cmp(am) <= 0; z.Add(z, intOne) {
+			tmp.Mul(z, z).Sub(tmp, D)
+			if tmp.Mod(tmp, am).Sign() != 0 {
+				continue
+			}
+			x, y := lmmSolution(D, z, am, m.Sign())
+			if x == nil {
+				continue
+			}
+			x, y = pellMinimal(x.Mul(x, f), y.Mul(y, f), t, u, D)
+			key := x.String() + " " + y.String()
+			if !seen[key] {
+				seen[key] = true
+				solutions = append(solutions, PellSolution{x, y})
+			}
+		}
+	}
+	return solutions, nil
+}
+
+// Expand (P0 + sqrt(D)) / Q0 until a denominator Q_k = +-1 with
+// (-1)^k Q_k = sign, and return G = A_{k-1} Q0 - P0 B_{k-1} and B_{k-1},
+// which satisfy G^2 - D B^2 = (-1)^k Q_k Q0; or nil if no complete
+// quotient in the pre-period or two periods has one.
+func lmmSolution(D, P0, Q0 *big.Int, sign int) (*big.Int, *big.Int) {
+	cf, err := QuadraticContinuedFraction(P0, D, Q0)
+	if err != nil {
+		return nil, nil
+	}
+	n := len(cf.terms) + 2*len(cf.period)
+	A, B := cf.convergents(n)
+	for k := 1; k <= n; k++ {
+		_, Q := cf.CompleteQuotient(k)
+		parity := 1 - 2*(k&1)
+		if Q.CmpAbs(intOne) == 0 && Q.Sign()*parity == sign {
+			G := new(big.Int).Mul(A[k-1], Q0)
+			return G.Sub(G, new(big.Int).Mul(P0, B[k-1])), B[k-1]
+		}
+	}
+	return nil, nil
+}
+
+// Compute x^2 - D y^2.
+func pellNorm(x, y, D *big.Int) *big.Int {
+	n := new(big.Int).Mul(x, x)
+	return n.Sub(n, new(big.Int).Mul(D, new(big.Int).Mul(y, y)))
+}
+
+// Compute the sign of x + y sqrt(D) != 0.
+func pellSign(x, y, D *big.Int) int {
+	if x.Sign()*y.Sign() >= 0 {
+		if x.Sign() != 0 {
+			return x.Sign()
+		}
+		return y.Sign()
+	}
+	if pellNorm(x, y, D).Sign() > 0 {
+		return x.Sign()
+	}
+	return y.Sign()
+}
+
+// Compute (x1 + y1 sqrt(D)) (x2 + y2 sqrt(D)).
+func pellMul(x1, y1, x2, y2, D *big.Int) (*big.Int, *big.Int) {
+	x := new(big.Int).Mul(x1, x2)
+	x.Add(x, new(big.Int).Mul(D, new(big.Int).Mul(y1, y2)))
+	y := new(big.Int).Mul(x1, y2)
+	y.Add(y, new(big.Int).Mul(y1, x2))
+	return x, y
+}
+
+// Move x + y sqrt(D) to the element of least |y| in its class under
+// multiplication by powers of the unit t + u sqrt(D), with x >= 0.
+func pellMinimal(x, y, t, u, D *big.Int) (*big.Int, *big.Int) {
+	nu := new(big.Int).Neg(u)
+	for {
+		if x1, y1 := pellMul(x, y, t, u, D); y1.CmpAbs(y) < 0 {
+			x, y = x1, y1
+		} else if x2, y2 := pellMul(x, y, t, nu, D); y2.CmpAbs(y) < 0 {
+			x, y = x2, y2
+		} else {
+			break
+		}
+	}
+	if x.Sign() < 0 || x.Sign() == 0 && y.Sign() < 0 {
+		x.Neg(x)
+		y.Neg(y)
+	}
+	return x, y
+}
+
+// Generate the solutions of x^2 - D y^2 = N with x > 0 and y >= 0, in
+// increasing order of x.
+type PellIterator struct {
+	d, t, u *big.Int
+	// The next element of each sequence s e^k, k >= 0, for s a
+	// fundamental solution or its conjugate.
+	heads []PellSolution
+}
+
+func NewPellIterator(D, N *big.Int) (*PellIterator, error) {
+	solutions, err := SolvePell(D, N)
+	if err != nil {
+		return nil, err
+	}
+	it := &PellIterator{d: new(big.Int).Set(D)}
+	it.t, it.u, _ = PellFundamentalSolution(D)
+	seen := map[string]bool{}
+	for _, s := range solutions {
+		for _, y := range []*big.Int{s.Y, new(big.Int).Neg(s.Y)} {
+			key := s.X.String() + " " + y.String()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			it.heads = append(it.heads, it.advance(PellSolution{s.X, y}, false))
+		}
+	}
+	return it, nil
+}
+
+// Return the first element of s e^k, k >= 0 if step is false and k >= 1
+// otherwise, with x > 0 and y >= 0.
+func (it *PellIterator) advance(s PellSolution, step bool) PellSolution {
+	x, y := s.X, s.Y
+	// Powers of e keep the sign of x + y sqrt(D).
+	if pellSign(x, y, it.d) < 0 {
+		x, y = new(big.Int).Neg(x), new(big.Int).Neg(y)
+	}
+	if step {
+		x, y = pellMul(x, y, it.t, it.u, it.d)
+	}
+	for x.Sign() <= 0 || y.Sign() < 0 {
+		x, y = pellMul(x, y, it.t, it.u, it.d)
+	}
+	return PellSolution{x, y}
+}
+
+// Return the next solution, or nil if the equation has none.
+func (it *PellIterator) Next() (*big.Int, *big.Int) {
+	if len(it.heads) == 0 {
+		return nil, nil
+	}
+	best := 0
+	for i, s := range it.heads {
+		if s.X.Cmp(it.heads[best].X) < 0 {
+			best = i
+		}
+	}
+	next := it.heads[best]
+	for i, s := range it.heads {
+		if s.X.Cmp(next.X) == 0 && s.Y.Cmp(next.Y) == 0 {
+			it.heads[i] = it.advance(s, true)
+		}
+	}
+	return new(big.Int).Set(next.X), new(big.Int).Set(next.Y)
+}
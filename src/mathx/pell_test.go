@@ -0,0 +1,107 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestPellFundamentalSolution(t *testing.T) {
+	testCases := []struct {
+		D    int64
+		x, y string
+	}{
+		{2, "3", "2"},
+		{13, "649", "180"},
+		{61, "1766319049", "226153980"},
+		{94, "2143295", "221064"},
+		{991, "379516400906811930638014896080", "12055735790331359447442538767"},
+	}
+	for _, testCase := range testCases {
+		x, y, err := PellFundamentalSolution(big.NewInt(testCase.D))
+		if err != nil || x.String() != testCase.x || y.String() != testCase.y {
+			t.Errorf("D = %d: expected (%s, %s), got (%v, %v) %v", testCase.D, testCase.x, testCase.y, x, y, err)
+		}
+	}
+	if _, _, err := PellFundamentalSolution(big.NewInt(49)); err != ErrInvalidPellEquation {
+		t.Errorf("expected ErrInvalidPellEquation, got %v", err)
+	}
+	// For D = a^2 + 1 the unit a + sqrt(D) has norm -1, and its square
+	// 2a^2 + 1 + 2a sqrt(D) is the least solution.
+	a := new(big.Int).Lsh(intOne, 100)
+	D := new(big.Int).Mul(a, a)
+	D.Add(D, intOne)
+	x, y, err := PellFundamentalSolution(D)
+	wantX := new(big.Int).Lsh(D, 1)
+	wantX.Sub(wantX, intOne)
+	if err != nil || x.Cmp(wantX) != 0 || y.Cmp(new(big.Int).Lsh(a, 1)) != 0 {
+		t.Errorf("D = 2^200 + 1: expected (%s, %s), got (%v, %v) %v", wantX, new(big.Int).Lsh(a, 1), x, y, err)
+	}
+}
+
+func TestSolvePell(t *testing.T) {
+	// x^2 - 10 y^2 = 9 has the classes of 3, 7 + 2 sqrt(10) and its
+	// conjugate; 13 + 4 sqrt(10) = (7 - 2 sqrt(10)) (19 + 6 sqrt(10)).
+	solutions, err := SolvePell(big.NewInt(10), big.NewInt(9))
+	if err != nil || len(solutions) != 3 {
+		t.Errorf("x^2 - 10 y^2 = 9: got %v %v", solutions, err)
+	}
+
+	// The iterator against a search over y.
+	for D := int64(2); D < 12; D++ {
+		if IsSquare(big.NewInt(D)) {
+			continue
+		}
+		for N := int64(-20); N <= 20; N++ {
+			if N == 0 {
+				continue
+			}
+			want := []string{}
+			for y := int64(0); y <= 1000; y++ {
+				x := big.NewInt(N + D*y*y)
+				if x.Sign() > 0 && IsSquare(x) && Sqrt(x).Int64() <= 1000 {
+					want = append(want, Sqrt(x).String()+" "+big.NewInt(y).String())
+				}
+			}
+			it, err := NewPellIterator(big.NewInt(D), big.NewInt(N))
+			if err != nil {
+				t.Fatalf("D = %d, N = %d: %v", D, N, err)
+			}
+			got := map[string]bool{}
+			last := big.NewInt(0)
+			for {
+				x, y := it.Next()
+				if x == nil || x.Cmp(big.NewInt(1000)) > 0 {
+					break
+				}
+				if x.Cmp(last) < 0 || pellNorm(x, y, big.NewInt(D)).Int64() != N {
+					t.Errorf("D = %d, N = %d: bad solution %v, %v after %v", D, N, x, y, last)
+				}
+				last = x
+				got[x.String()+" "+y.String()] = true
+			}
+			if len(got) != len(want) {
+				t.Errorf("D = %d, N = %d: expected %v, got %v", D, N, want, got)
+			}
+			for _, s := range want {
+				if !got[s] {
+					t.Errorf("D = %d, N = %d: missing %s", D, N, s)
+				}
+			}
+		}
+	}
+}
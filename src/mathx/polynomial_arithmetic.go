@@ -0,0 +1,235 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"math/big"
+)
+
+// Below this many coefficients, schoolbook multiplication is faster
+// than Karatsuba.
+const karatsubaThreshold = 32
+
+func (p *IntPolynomial) Copy() *IntPolynomial {
+	q := new(IntPolynomial)
+	q.coeffs = make([]big.Int, len(p.coeffs))
+	for i := range p.coeffs {
+		q.coeffs[i].Set(&p.coeffs[i])
+	}
+	return q
+}
+
+func (p *IntPolynomial) IsZero() bool {
+	return len(p.coeffs) == 0
+}
+
+// Return a copy of the coefficient of x^i, which is zero if i is
+// larger than the degree.
+func (p *IntPolynomial) Coeff(i int) *big.Int {
+	if i < 0 || i >= len(p.coeffs) {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(&p.coeffs[i])
+}
+
+// Return a copy of the leading coefficient, or zero for the zero
+// polynomial.
+func (p *IntPolynomial) LeadingCoeff() *big.Int {
+	return p.Coeff(p.Degree())
+}
+
+func (p *IntPolynomial) Equal(q *IntPolynomial) bool {
+	if len(p.coeffs) != len(q.coeffs) {
+		return false
+	}
+	for i := range p.coeffs {
+		if p.coeffs[i].Cmp(&q.coeffs[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *IntPolynomial) Add(q *IntPolynomial) *IntPolynomial {
+	r := new(IntPolynomial)
+	r.coeffs = addCoeffs(p.coeffs, q.coeffs)
+	return r.trim()
+}
+
+func (p *IntPolynomial) Sub(q *IntPolynomial) *IntPolynomial {
+	r := new(IntPolynomial)
+	r.coeffs = subCoeffs(p.coeffs, q.coeffs)
+	return r.trim()
+}
+
+func (p *IntPolynomial) Neg() *IntPolynomial {
+	r := p.Copy()
+	for i := range r.coeffs {
+		r.coeffs[i].Neg(&r.coeffs[i])
+	}
+	return r
+}
+
+func (p *IntPolynomial) Mul(q *IntPolynomial) *IntPolynomial {
+	r := new(IntPolynomial)
+	r.coeffs = mulCoeffs(p.coeffs, q.coeffs)
+	return r.trim()
+}
+
+// Multiply every coefficient by c.
+func (p *IntPolynomial) MulScalar(c *big.Int) *IntPolynomial {
+	r := p.Copy()
+	for i := range r.coeffs {
+		r.coeffs[i].Mul(&r.coeffs[i], c)
+	}
+	return r.trim()
+}
+
+func (p *IntPolynomial) Mul64(c int64) *IntPolynomial {
+	return p.MulScalar(big.NewInt(c))
+}
+
+// Compute p^n by repeated squaring. p^0 is 1, even for p = 0.
+func (p *IntPolynomial) Pow(n uint) *IntPolynomial {
+	r := NewIntPolynomial64(1)
+	s := p
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			r = r.Mul(s)
+		}
+		if n > 1 {
+			s = s.Mul(s)
+		}
+	}
+	return r
+}
+
+// Evaluate p at x using Horner's rule.
+func (p *IntPolynomial) Eval(x *big.Int) *big.Int {
+	y := big.NewInt(0)
+	for i := len(p.coeffs) - 1; i >= 0; i-- {
+		y.Mul(y, x)
+		y.Add(y, &p.coeffs[i])
+	}
+	return y
+}
+
+func addCoeffs(a, b []big.Int) []big.Int {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	c := make([]big.Int, len(a))
+	for i := range a {
+		if i < len(b) {
+			c[i].Add(&a[i], &b[i])
+		} else {
+			c[i].Set(&a[i])
+		}
+	}
+	return c
+}
+
+func subCoeffs(a, b []big.Int) []big.Int {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	c := make([]big.Int, n)
+	for i := range c {
+		if i < len(a) {
+			c[i].Set(&a[i])
+		}
+		if i < len(b) {
+			c[i].Sub(&c[i], &b[i])
+		}
+	}
+	return c
+}
+
+func mulCoeffs(a, b []big.Int) []big.Int {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+	if len(a) < karatsubaThreshold || len(b) < karatsubaThreshold {
+		return mulSchoolbook(a, b)
+	}
+	return mulKaratsuba(a, b)
+}
+
+func mulSchoolbook(a, b []big.Int) []big.Int {
+	c := make([]big.Int, len(a)+len(b)-1)
+	t := big.NewInt(0)
+	for i := range a {
+		if a[i].Sign() == 0 {
+			continue
+		}
+		for j := range b {
+			t.Mul(&a[i], &b[j])
+			c[i+j].Add(&c[i+j], t)
+		}
+	}
+	return c
+}
+
+// Karatsuba multiplication: split a = a0 + a1 x^m and b = b0 + b1 x^m,
+// then a*b = z0 + ((a0 + a1)(b0 + b1) - z0 - z2) x^m + z2 x^2m.
+func mulKaratsuba(a, b []big.Int) []big.Int {
+	m := len(a)
+	if len(b) > m {
+		m = len(b)
+	}
+	m = (m + 1) / 2
+	a0, a1 := splitCoeffs(a, m)
+	b0, b1 := splitCoeffs(b, m)
+
+	z0 := mulCoeffs(a0, b0)
+	z2 := mulCoeffs(a1, b1)
+	z1 := mulCoeffs(addCoeffs(a0, a1), addCoeffs(b0, b1))
+	z1 = subCoeffs(subCoeffs(z1, z0), z2)
+
+	c := make([]big.Int, len(a)+len(b)-1)
+	for i := range z0 {
+		c[i].Add(&c[i], &z0[i])
+	}
+	for i := range z1 {
+		if i+m < len(c) {
+			c[i+m].Add(&c[i+m], &z1[i])
+		}
+	}
+	for i := range z2 {
+		c[i+2*m].Add(&c[i+2*m], &z2[i])
+	}
+	return c
+}
+
+func splitCoeffs(a []big.Int, m int) ([]big.Int, []big.Int) {
+	if len(a) <= m {
+		return a, nil
+	}
+	return a[:m], a[m:]
+}
+
+func (p *IntPolynomial) Derivative() *IntPolynomial {
+	if p.Degree() < 1 {
+		return new(IntPolynomial)
+	}
+	r := new(IntPolynomial)
+	r.coeffs = make([]big.Int, p.Degree())
+	for i := range r.coeffs {
+		r.coeffs[i].Mul(&p.coeffs[i+1], big.NewInt(int64(i+1)))
+	}
+	return r.trim()
+}
@@ -30,6 +30,28 @@ func NewIntPolynomial64(coeffs ...int64) *IntPolynomial {
 	for i, c := range coeffs {
 		p.coeffs[i].SetInt64(c)
 	}
+	return p.trim()
+}
+
+// Create a polynomial from its coefficients, constant term first.
+// The coefficients are copied.
+func NewIntPolynomial(coeffs []*big.Int) *IntPolynomial {
+	p := new(IntPolynomial)
+	p.coeffs = make([]big.Int, len(coeffs))
+	for i, c := range coeffs {
+		p.coeffs[i].Set(c)
+	}
+	return p.trim()
+}
+
+// Remove leading zero coefficients, so that the zero polynomial has
+// no coefficients at all and degree -1.
+func (p *IntPolynomial) trim() *IntPolynomial {
+	n := len(p.coeffs)
+	for n > 0 && p.coeffs[n-1].Sign() == 0 {
+		n--
+	}
+	p.coeffs = p.coeffs[:n]
 	return p
 }
 
@@ -37,69 +59,23 @@ func (p *IntPolynomial) Degree() int {
 	return len(p.coeffs) - 1
 }
 
+// Tell if p is irreducible in Z[x]: p must be primitive and have no
+// nontrivial factorization.
 func (p *IntPolynomial) IsIrreducible() bool {
-	g := p.coeffs[0]
-	if g.Sign() == 0 {
+	if p.Degree() < 1 {
 		return false
 	}
-	// check the gcd of the coefficients
-	for _, c := range p.coeffs {
-		if c.Sign() == 0 {
-			continue
-		}
-		(&g).GCD(nil, nil, &g, &c)
-		if g.Cmp(intOne) == 0 {
-			break
-		}
-	}
-	if g.Cmp(intOne) != 0 {
+	if p.Content().Cmp(intOne) != 0 {
 		return false
 	}
 	if p.Degree() == 1 {
 		return true
 	}
 	if p.Degree() == 2 {
-		c, b, a := p.coeffs[0], p.coeffs[1], p.coeffs[2]
-		b2 := big.NewInt(0)
-		b2.Mul(&b, &b)
-		ac4 := big.NewInt(4)
-		ac4.Mul(ac4, &a)
-		ac4.Mul(ac4, &c)
-		b2.Sub(b2, ac4)
-		return !IsSquare(b2)
+		return !IsSquare(p.Discriminant())
 	}
-	/*
-		// check the gcd of the coefficients
-		a0 := p.coeffs[0].Int64()
-		g.SetInt64(a0)
-		for i, c := range p.coeffs {
-			if i == 0 {
-				continue
-			}
-			if i == len(p.coeffs)-1 {
-				break
-			}
-			if c.Sign() == 0 {
-				continue
-			}
-			g.GCD(nil, nil, &g, &c)
-		}
-		if g.Cmp(intOne) != 0 {
-			sufficient := true
-			for _, primeExp := range Factorization64(g.Int64()) {
-				p := primeExp.prime
-				if a0%(p*p) == 0 {
-					sufficient = false
-					break
-				}
-			}
-			if sufficient {
-				return true
-			}
-		}
-	*/
-	// TODO: implement more cases
-	return true
+	_, factors := p.Factor()
+	return len(factors) == 1 && factors[0].Exponent == 1
 }
 
 func xstring(i int) string {
@@ -112,85 +88,17 @@ func xstring(i int) string {
 	return "x^" + strconv.Itoa(i)
 }
 
-func eliminateSpaces(s string) string {
-	t := ""
-	for _, c := range s {
-		if c != ' ' {
-			t += string(c)
-		}
-	}
-	return t
-}
-
+// Parse a polynomial, panicking with the ParseError message if the string
+// is malformed, for polynomials written into the program. Kept for
+// compatibility; new code should use ParseIntPolynomial.
 func ParseIntPoly(s string) *IntPolynomial {
-	s = eliminateSpaces(s)
-	coeffs := make([]big.Int, 0)
-
-	neg := false
-	inX := false
-	degree := ""
-	coeff := ""
-	firstChar := true
-	for _, c := range s {
-		switch {
-		case c == 'x':
-			inX = true
-		case c >= '0' && c <= '9':
-			if inX {
-				degree += string(c)
-			} else {
-				coeff += string(c)
-			}
-		case c == '+' || c == '-':
-			if inX {
-				if len(degree) == 0 {
-					degree = "1"
-				}
-				if len(coeff) == 0 {
-					coeff = "1"
-				}
-			}
-			inX = false
-			if !firstChar {
-				coeffs = setCoeff(coeffs, degree, coeff, neg)
-			}
-			coeff = ""
-			degree = ""
-			neg = false
-			if c == '-' {
-				neg = true
-			}
-		case c == '^':
-		default:
-			// Do nothing.
-		}
-		firstChar = false
+	p, err := ParseIntPolynomial(s)
+	if err != nil {
+		panic(err.Error())
 	}
-	if inX {
-		if len(degree) == 0 {
-			degree = "1"
-		}
-		if len(coeff) == 0 {
-			coeff = "1"
-		}
-	}
-	p := new(IntPolynomial)
-	p.coeffs = setCoeff(coeffs, degree, coeff, neg)
 	return p
 }
 
-func setCoeff(coeffs []big.Int, degreeS, coeff string, neg bool) []big.Int {
-	degree, _ := strconv.Atoi(degreeS)
-	for degree >= len(coeffs) {
-		coeffs = append(coeffs, *big.NewInt(0))
-	}
-	coeffs[degree].SetString(coeff, 10)
-	if neg {
-		coeffs[degree].Neg(&coeffs[degree])
-	}
-	return coeffs
-}
-
 func (p *IntPolynomial) String() string {
 	if p == nil {
 		return "<nil>"
@@ -0,0 +1,244 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrDivisionByZero = errors.New("mathx: polynomial division by zero")
+	ErrNotDivisible   = errors.New("mathx: polynomial division is not exact over the integers")
+)
+
+// Divide p by q, giving quotient and remainder with p = quo*q + rem and
+// deg rem < deg q. This fails with ErrNotDivisible if the quotient
+// does not have integer coefficients; it always succeeds when q is
+// monic.
+func (p *IntPolynomial) DivMod(q *IntPolynomial) (*IntPolynomial, *IntPolynomial, error) {
+	if q.IsZero() {
+		return nil, nil, ErrDivisionByZero
+	}
+	n := q.Degree()
+	lc := &q.coeffs[n]
+	r := p.Copy()
+	quo := new(IntPolynomial)
+	if r.Degree() >= n {
+		quo.coeffs = make([]big.Int, r.Degree()-n+1)
+	}
+	c := big.NewInt(0)
+	m := big.NewInt(0)
+	t := big.NewInt(0)
+	for r.Degree() >= n {
+		d := r.Degree()
+		c.QuoRem(&r.coeffs[d], lc, m)
+		if m.Sign() != 0 {
+			return nil, nil, ErrNotDivisible
+		}
+		quo.coeffs[d-n].Set(c)
+		for i := 0; i <= n; i++ {
+			t.Mul(c, &q.coeffs[i])
+			r.coeffs[d-n+i].Sub(&r.coeffs[d-n+i], t)
+		}
+		r.trim()
+	}
+	return quo.trim(), r, nil
+}
+
+// Divide p by q, returning an error unless q divides p exactly in Z[x].
+func (p *IntPolynomial) ExactDiv(q *IntPolynomial) (*IntPolynomial, error) {
+	quo, rem, err := p.DivMod(q)
+	if err != nil {
+		return nil, err
+	}
+	if !rem.IsZero() {
+		return nil, ErrNotDivisible
+	}
+	return quo, nil
+}
+
+// Pseudo-division: compute quo and rem with
+// lc(q)^(deg p - deg q + 1) * p = quo*q + rem and deg rem < deg q.
+// Cohen, Alg. 3.1.2.
+func (p *IntPolynomial) PseudoDivMod(q *IntPolynomial) (*IntPolynomial, *IntPolynomial, error) {
+	if q.IsZero() {
+		return nil, nil, ErrDivisionByZero
+	}
+	m := p.Degree()
+	n := q.Degree()
+	if m < n {
+		return new(IntPolynomial), p.Copy(), nil
+	}
+	d := &q.coeffs[n]
+	r := p.Copy()
+	quo := new(IntPolynomial)
+	e := m - n + 1
+	for !r.IsZero() && r.Degree() >= n {
+		s := new(IntPolynomial)
+		s.coeffs = make([]big.Int, r.Degree()-n+1)
+		s.coeffs[r.Degree()-n].Set(&r.coeffs[r.Degree()])
+		quo = quo.MulScalar(d).Add(s)
+		r = r.MulScalar(d).Sub(s.Mul(q))
+		e--
+	}
+	de := new(big.Int).Exp(d, big.NewInt(int64(e)), nil)
+	return quo.MulScalar(de), r.MulScalar(de), nil
+}
+
+// Return the positive gcd of the coefficients, or zero for the zero
+// polynomial.
+func (p *IntPolynomial) Content() *big.Int {
+	g := big.NewInt(0)
+	for i := range p.coeffs {
+		if p.coeffs[i].Sign() == 0 {
+			continue
+		}
+		if g.Sign() == 0 {
+			g.Abs(&p.coeffs[i])
+		} else {
+			g.GCD(nil, nil, g, new(big.Int).Abs(&p.coeffs[i]))
+		}
+		if g.Cmp(intOne) == 0 {
+			break
+		}
+	}
+	return g
+}
+
+// Return p divided by its content, so that p = Content() * PrimitivePart().
+func (p *IntPolynomial) PrimitivePart() *IntPolynomial {
+	c := p.Content()
+	if c.Sign() == 0 {
+		return p.Copy()
+	}
+	return p.quoScalar(c)
+}
+
+// Divide every coefficient by c, which must divide all of them.
+func (p *IntPolynomial) quoScalar(c *big.Int) *IntPolynomial {
+	q := p.Copy()
+	for i := range q.coeffs {
+		q.coeffs[i].Quo(&q.coeffs[i], c)
+	}
+	return q
+}
+
+// Make the leading coefficient positive.
+func (p *IntPolynomial) normalizeSign() *IntPolynomial {
+	if !p.IsZero() && p.coeffs[p.Degree()].Sign() < 0 {
+		return p.Neg()
+	}
+	return p
+}
+
+// Compute the greatest common divisor of p and q in Z[x], normalized
+// to have a positive leading coefficient.
+// Uses the sub-resultant algorithm, Cohen, Alg. 3.3.1.
+func (p *IntPolynomial) GCD(q *IntPolynomial) *IntPolynomial {
+	a, b := p, q
+	if a.Degree() < b.Degree() {
+		a, b = b, a
+	}
+	if b.IsZero() {
+		return a.Copy().normalizeSign()
+	}
+	d := new(big.Int).GCD(nil, nil, a.Content(), b.Content())
+	a = a.PrimitivePart()
+	b = b.PrimitivePart()
+	g := big.NewInt(1)
+	h := big.NewInt(1)
+	for {
+		delta := a.Degree() - b.Degree()
+		_, r, _ := a.PseudoDivMod(b)
+		if r.IsZero() {
+			return b.PrimitivePart().MulScalar(d).normalizeSign()
+		}
+		if r.Degree() == 0 {
+			return NewIntPolynomial([]*big.Int{d})
+		}
+		a = b
+		gh := new(big.Int).Exp(h, big.NewInt(int64(delta)), nil)
+		gh.Mul(gh, g)
+		b = r.quoScalar(gh)
+		g.Set(&a.coeffs[a.Degree()])
+		// h = g^delta / h^(delta - 1)
+		if delta > 0 {
+			t := new(big.Int).Exp(g, big.NewInt(int64(delta)), nil)
+			h.Quo(t, new(big.Int).Exp(h, big.NewInt(int64(delta-1)), nil))
+		}
+	}
+}
+
+// Compute the resultant of p and q.
+// Uses the sub-resultant algorithm, Cohen, Alg. 3.3.7.
+func (p *IntPolynomial) Resultant(q *IntPolynomial) *big.Int {
+	if p.IsZero() || q.IsZero() {
+		return big.NewInt(0)
+	}
+	if q.Degree() == 0 {
+		return new(big.Int).Exp(&q.coeffs[0], big.NewInt(int64(p.Degree())), nil)
+	}
+	if p.Degree() == 0 {
+		return new(big.Int).Exp(&p.coeffs[0], big.NewInt(int64(q.Degree())), nil)
+	}
+	a, b := p, q
+	s := 1
+	if a.Degree() < b.Degree() {
+		a, b = b, a
+		if a.Degree()&1 == 1 && b.Degree()&1 == 1 {
+			s = -s
+		}
+	}
+	ca := new(big.Int).Exp(a.Content(), big.NewInt(int64(b.Degree())), nil)
+	cb := new(big.Int).Exp(b.Content(), big.NewInt(int64(a.Degree())), nil)
+	t := ca.Mul(ca, cb)
+	a = a.PrimitivePart()
+	b = b.PrimitivePart()
+	g := big.NewInt(1)
+	h := big.NewInt(1)
+	for {
+		delta := a.Degree() - b.Degree()
+		if a.Degree()&1 == 1 && b.Degree()&1 == 1 {
+			s = -s
+		}
+		_, r, _ := a.PseudoDivMod(b)
+		if r.IsZero() {
+			return big.NewInt(0)
+		}
+		a = b
+		gh := new(big.Int).Exp(h, big.NewInt(int64(delta)), nil)
+		gh.Mul(gh, g)
+		b = r.quoScalar(gh)
+		g.Set(&a.coeffs[a.Degree()])
+		// h = g^delta / h^(delta - 1)
+		if delta > 0 {
+			u := new(big.Int).Exp(g, big.NewInt(int64(delta)), nil)
+			h.Quo(u, new(big.Int).Exp(h, big.NewInt(int64(delta-1)), nil))
+		}
+		if b.Degree() == 0 {
+			// h = lc(b)^deg(a) / h^(deg(a) - 1)
+			n := int64(a.Degree())
+			u := new(big.Int).Exp(&b.coeffs[0], big.NewInt(n), nil)
+			h.Quo(u, new(big.Int).Exp(h, big.NewInt(n-1), nil))
+			h.Mul(h, t)
+			if s < 0 {
+				h.Neg(h)
+			}
+			return h
+		}
+	}
+}
@@ -0,0 +1,309 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"math/big"
+	"math/rand"
+	"sort"
+)
+
+// How many good primes to try before settling on the one giving the
+// fewest modular factors.
+const factorPrimeTrials = 5
+
+// An irreducible factor of a polynomial and its multiplicity.
+type PolynomialFactor struct {
+	Factor   *IntPolynomial
+	Exponent int
+}
+
+// Compute the square-free decomposition of a primitive polynomial.
+// Entry i of the result is the product of the factors of multiplicity
+// exactly i (entry 0 is unused), each primitive with positive leading
+// coefficient.
+// Cohen, Alg. 3.4.2.
+func (p *IntPolynomial) SquareFreeDecomposition() []*IntPolynomial {
+	f := p.PrimitivePart().normalizeSign()
+	parts := []*IntPolynomial{nil}
+	if f.Degree() < 1 {
+		return parts
+	}
+	g := f.GCD(f.Derivative())
+	h, _ := f.ExactDiv(g)
+	h = h.PrimitivePart().normalizeSign()
+	for h.Degree() > 0 {
+		h2 := g.GCD(h).PrimitivePart()
+		a, _ := h.ExactDiv(h2)
+		parts = append(parts, a.normalizeSign())
+		g, _ = g.ExactDiv(h2)
+		h = h2.normalizeSign()
+	}
+	return parts
+}
+
+// Factor p into irreducibles over Z. Returns the signed content c and
+// primitive irreducible factors g_i with positive leading coefficients
+// such that p = c * g_1^e_1 * ... * g_k^e_k.
+// Square-free decomposition, factorization modulo a small prime,
+// Hensel lifting and Zassenhaus recombination; Cohen, Alg. 3.5.7.
+func (p *IntPolynomial) Factor() (*big.Int, []PolynomialFactor) {
+	c := p.Content()
+	if p.Degree() < 1 {
+		return p.LeadingCoeff(), nil
+	}
+	if p.LeadingCoeff().Sign() < 0 {
+		c.Neg(c)
+	}
+	factors := []PolynomialFactor{}
+	rng := rand.New(rand.NewSource(1))
+	for e, part := range p.SquareFreeDecomposition() {
+		if part == nil || part.Degree() < 1 {
+			continue
+		}
+		for _, g := range part.factorSquareFree(rng) {
+			factors = append(factors, PolynomialFactor{g, e})
+		}
+	}
+	sort.Sort(polynomialFactors(factors))
+	return c, factors
+}
+
+type polynomialFactors []PolynomialFactor
+
+func (f polynomialFactors) Len() int      { return len(f) }
+func (f polynomialFactors) Swap(i, j int) { f[i], f[j] = f[j], f[i] }
+func (f polynomialFactors) Less(i, j int) bool {
+	a, b := f[i].Factor, f[j].Factor
+	if a.Degree() != b.Degree() {
+		return a.Degree() < b.Degree()
+	}
+	for k := a.Degree(); k >= 0; k-- {
+		if c := a.coeffs[k].Cmp(&b.coeffs[k]); c != 0 {
+			return c < 0
+		}
+	}
+	return f[i].Exponent < f[j].Exponent
+}
+
+// Factor a primitive, square-free polynomial with positive leading
+// coefficient.
+func (f *IntPolynomial) factorSquareFree(rng *rand.Rand) []*IntPolynomial {
+	if f.Degree() == 1 {
+		return []*IntPolynomial{f}
+	}
+	p, local := f.chooseFactorPrime(rng)
+	if len(local) == 1 {
+		return []*IntPolynomial{f}
+	}
+
+	// Lift until p^k exceeds twice the largest coefficient of
+	// lc(f) times any factor of f.
+	bound := f.factorCoeffBound()
+	bound.Mul(bound, f.LeadingCoeff())
+	bound.Lsh(bound, 1)
+	m := new(big.Int).Set(p)
+	for m.Cmp(bound) <= 0 {
+		m.Mul(m, m)
+	}
+	lifted := henselLift(f, local, p, m)
+	return zassenhaus(f, lifted, m)
+}
+
+// Find an odd prime not dividing the leading coefficient modulo which f
+// stays square-free, preferring one that gives few modular factors.
+// Returns the prime and the monic factors of f modulo it.
+func (f *IntPolynomial) chooseFactorPrime(rng *rand.Rand) (*big.Int, []*ModPolynomial) {
+	var best *big.Int
+	var bestFactors []*ModPolynomial
+	lc := f.LeadingCoeff()
+	trials := 0
+	for i := 1; trials < factorPrimeTrials; i++ {
+		if i >= len(primes) {
+			genPrimes(2 * primes[len(primes)-1])
+		}
+		p := big.NewInt(primes[i])
+		if new(big.Int).Mod(lc, p).Sign() == 0 {
+			continue
+		}
+		fp := f.ModP(p)
+		if fp.GCD(fp.Derivative()).Degree() > 0 {
+			continue
+		}
+		trials++
+		local := fp.Monic().factorSquareFree(rng)
+		if best == nil || len(local) < len(bestFactors) {
+			best, bestFactors = p, local
+		}
+		if len(local) == 1 {
+			break
+		}
+	}
+	return best, bestFactors
+}
+
+// Bound the absolute value of the coefficients of any factor of f,
+// 2^deg(f) * ||f||_2 (Mignotte).
+func (f *IntPolynomial) factorCoeffBound() *big.Int {
+	norm := big.NewInt(0)
+	t := big.NewInt(0)
+	for i := range f.coeffs {
+		t.Mul(&f.coeffs[i], &f.coeffs[i])
+		norm.Add(norm, t)
+	}
+	norm = Sqrt(norm)
+	norm.Add(norm, intOne)
+	return norm.Lsh(norm, uint(f.Degree()))
+}
+
+// Reduce the coefficients of p into [0, m).
+func (p *IntPolynomial) reduceMod(m *big.Int) *IntPolynomial {
+	q := p.Copy()
+	for i := range q.coeffs {
+		q.coeffs[i].Mod(&q.coeffs[i], m)
+	}
+	return q.trim()
+}
+
+// Reduce the coefficients of p into (-m/2, m/2].
+func (p *IntPolynomial) symmetricMod(m *big.Int) *IntPolynomial {
+	half := new(big.Int).Rsh(m, 1)
+	q := p.reduceMod(m)
+	for i := range q.coeffs {
+		if q.coeffs[i].Cmp(half) > 0 {
+			q.coeffs[i].Sub(&q.coeffs[i], m)
+		}
+	}
+	return q.trim()
+}
+
+// Lift the factorization f = lc(f) * u_1 * ... * u_r modulo p, with
+// each u_i monic, to a factorization modulo m, a power of p, using a
+// balanced factor tree.
+func henselLift(f *IntPolynomial, local []*ModPolynomial, p, m *big.Int) []*IntPolynomial {
+	if len(local) == 1 {
+		inv := new(big.Int).ModInverse(f.LeadingCoeff(), m)
+		return []*IntPolynomial{f.MulScalar(inv).reduceMod(m)}
+	}
+	k := len(local) / 2
+	g := newModPolynomial(p, []big.Int{*f.LeadingCoeff()})
+	for _, u := range local[:k] {
+		g = g.Mul(u)
+	}
+	h := newModPolynomial(p, []big.Int{*big.NewInt(1)})
+	for _, u := range local[k:] {
+		h = h.Mul(u)
+	}
+	_, s, t := g.ExtendedGCD(h)
+	G, H := g.Lift(), h.Lift()
+	S, T := s.Lift(), t.Lift()
+	for q := new(big.Int).Set(p); q.Cmp(m) < 0; {
+		q.Mul(q, q)
+		G, H, S, T = henselStep(f, G, H, S, T, q)
+	}
+	G = G.reduceMod(m)
+	H = H.reduceMod(m)
+	return append(henselLift(G, local[:k], p, m), henselLift(H, local[k:], p, m)...)
+}
+
+// One quadratic Hensel step: given f = g*h and s*g + t*h = 1 modulo
+// sqrt(m), with h monic, return the same modulo m.
+// von zur Gathen and Gerhard, Alg. 15.10.
+func henselStep(f, g, h, s, t *IntPolynomial, m *big.Int) (*IntPolynomial, *IntPolynomial, *IntPolynomial, *IntPolynomial) {
+	e := f.Sub(g.Mul(h)).reduceMod(m)
+	q, r, _ := s.Mul(e).reduceMod(m).DivMod(h)
+	g2 := g.Add(t.Mul(e)).Add(q.Mul(g)).reduceMod(m)
+	h2 := h.Add(r).reduceMod(m)
+
+	b := s.Mul(g2).Add(t.Mul(h2)).Sub(NewIntPolynomial64(1)).reduceMod(m)
+	c, d, _ := s.Mul(b).reduceMod(m).DivMod(h2)
+	s2 := s.Sub(d).reduceMod(m)
+	t2 := t.Sub(t.Mul(b)).Sub(c.Mul(g2)).reduceMod(m)
+	return g2, h2, s2, t2
+}
+
+// Recombine the monic factors of f modulo m into the true factors of f
+// by trying products of subsets of increasing size.
+// Cohen, Alg. 3.5.7, steps 4 to 6.
+func zassenhaus(f *IntPolynomial, local []*IntPolynomial, m *big.Int) []*IntPolynomial {
+	factors := []*IntPolynomial{}
+	for s := 1; 2*s <= len(local); s++ {
+		subset := make([]int, s)
+		for i := range subset {
+			subset[i] = i
+		}
+		for {
+			lc := f.LeadingCoeff()
+			g := NewIntPolynomial([]*big.Int{lc})
+			for _, i := range subset {
+				g = g.Mul(local[i]).symmetricMod(m)
+			}
+			g = g.PrimitivePart()
+			if q, err := f.ExactDiv(g); err == nil {
+				factors = append(factors, g.normalizeSign())
+				f = q
+				rest := []*IntPolynomial{}
+				for i := range local {
+					if !containsInt(subset, i) {
+						rest = append(rest, local[i])
+					}
+				}
+				local = rest
+				if 2*s > len(local) {
+					break
+				}
+				for i := range subset {
+					subset[i] = i
+				}
+				continue
+			}
+			if !nextSubset(subset, len(local)) {
+				break
+			}
+		}
+	}
+	if f.Degree() > 0 {
+		factors = append(factors, f.normalizeSign())
+	}
+	return factors
+}
+
+func containsInt(a []int, x int) bool {
+	for _, y := range a {
+		if x == y {
+			return true
+		}
+	}
+	return false
+}
+
+// Advance subset, a strictly increasing list of indices below n, to the
+// next one in lexicographic order. Returns false when there is none.
+func nextSubset(subset []int, n int) bool {
+	k := len(subset)
+	i := k - 1
+	for i >= 0 && subset[i] == n-k+i {
+		i--
+	}
+	if i < 0 {
+		return false
+	}
+	subset[i]++
+	for j := i + 1; j < k; j++ {
+		subset[j] = subset[j-1] + 1
+	}
+	return true
+}
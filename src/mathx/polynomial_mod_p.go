@@ -0,0 +1,587 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"math/big"
+	"math/rand"
+	"sort"
+)
+
+// A polynomial with coefficients in Z/pZ for a prime p, stored as
+// residues in [0, p), constant term first. The prime may be of any
+// size.
+type ModPolynomial struct {
+	coeffs []big.Int
+	p      *big.Int
+}
+
+// An irreducible factor of a polynomial over Z/pZ and its multiplicity.
+type ModPolynomialFactor struct {
+	Factor   *ModPolynomial
+	Exponent int
+}
+
+// Create a polynomial over Z/pZ from its coefficients, constant term
+// first. p must be prime; the coefficients are reduced modulo p.
+func NewModPolynomial(p *big.Int, coeffs []*big.Int) *ModPolynomial {
+	c := make([]big.Int, len(coeffs))
+	for i := range coeffs {
+		c[i].Set(coeffs[i])
+	}
+	return newModPolynomial(new(big.Int).Set(p), c)
+}
+
+func NewModPolynomial64(p int64, coeffs ...int64) *ModPolynomial {
+	return NewIntPolynomial64(coeffs...).ModP(big.NewInt(p))
+}
+
+func newModPolynomial(p *big.Int, coeffs []big.Int) *ModPolynomial {
+	f := new(ModPolynomial)
+	f.p = p
+	f.coeffs = make([]big.Int, len(coeffs))
+	for i := range coeffs {
+		f.coeffs[i].Mod(&coeffs[i], p)
+	}
+	return f.trim()
+}
+
+// Reduce the coefficients of p modulo the prime q.
+func (p *IntPolynomial) ModP(q *big.Int) *ModPolynomial {
+	return newModPolynomial(q, p.coeffs)
+}
+
+// Lift to an integer polynomial with coefficients in [0, p).
+func (f *ModPolynomial) Lift() *IntPolynomial {
+	p := new(IntPolynomial)
+	p.coeffs = make([]big.Int, len(f.coeffs))
+	for i := range f.coeffs {
+		p.coeffs[i].Set(&f.coeffs[i])
+	}
+	return p
+}
+
+func (f *ModPolynomial) trim() *ModPolynomial {
+	n := len(f.coeffs)
+	for n > 0 && f.coeffs[n-1].Sign() == 0 {
+		n--
+	}
+	f.coeffs = f.coeffs[:n]
+	return f
+}
+
+func (f *ModPolynomial) Degree() int {
+	return len(f.coeffs) - 1
+}
+
+func (f *ModPolynomial) IsZero() bool {
+	return len(f.coeffs) == 0
+}
+
+func (f *ModPolynomial) IsOne() bool {
+	return len(f.coeffs) == 1 && f.coeffs[0].Cmp(intOne) == 0
+}
+
+func (f *ModPolynomial) Modulus() *big.Int {
+	return new(big.Int).Set(f.p)
+}
+
+// Return the coefficient of x^i as a residue in [0, p).
+func (f *ModPolynomial) Coeff(i int) *big.Int {
+	if i < 0 || i >= len(f.coeffs) {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(&f.coeffs[i])
+}
+
+func (f *ModPolynomial) LeadingCoeff() *big.Int {
+	return f.Coeff(f.Degree())
+}
+
+func (f *ModPolynomial) String() string {
+	if f == nil {
+		return "<nil>"
+	}
+	return f.Lift().String() + " (mod " + f.p.String() + ")"
+}
+
+func (f *ModPolynomial) Copy() *ModPolynomial {
+	return newModPolynomial(f.p, f.coeffs)
+}
+
+func (f *ModPolynomial) Equal(g *ModPolynomial) bool {
+	if len(f.coeffs) != len(g.coeffs) || f.p.Cmp(g.p) != 0 {
+		return false
+	}
+	for i := range f.coeffs {
+		if f.coeffs[i].Cmp(&g.coeffs[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *ModPolynomial) Add(g *ModPolynomial) *ModPolynomial {
+	return newModPolynomial(f.p, addCoeffs(f.coeffs, g.coeffs))
+}
+
+func (f *ModPolynomial) Sub(g *ModPolynomial) *ModPolynomial {
+	return newModPolynomial(f.p, subCoeffs(f.coeffs, g.coeffs))
+}
+
+func (f *ModPolynomial) Neg() *ModPolynomial {
+	return newModPolynomial(f.p, f.Lift().Neg().coeffs)
+}
+
+func (f *ModPolynomial) Mul(g *ModPolynomial) *ModPolynomial {
+	return newModPolynomial(f.p, mulCoeffs(f.coeffs, g.coeffs))
+}
+
+func (f *ModPolynomial) MulScalar(c *big.Int) *ModPolynomial {
+	h := f.Copy()
+	for i := range h.coeffs {
+		h.coeffs[i].Mul(&h.coeffs[i], c)
+		h.coeffs[i].Mod(&h.coeffs[i], f.p)
+	}
+	return h.trim()
+}
+
+// Divide by the leading coefficient.
+func (f *ModPolynomial) Monic() *ModPolynomial {
+	if f.IsZero() {
+		return f.Copy()
+	}
+	inv := new(big.Int).ModInverse(&f.coeffs[f.Degree()], f.p)
+	return f.MulScalar(inv)
+}
+
+// Divide f by g, which must be nonzero.
+func (f *ModPolynomial) DivMod(g *ModPolynomial) (*ModPolynomial, *ModPolynomial) {
+	n := g.Degree()
+	if n < 0 {
+		panic("division by zero is undefined\n")
+	}
+	r := f.Copy()
+	q := new(ModPolynomial)
+	q.p = f.p
+	if r.Degree() < n {
+		return q, r
+	}
+	q.coeffs = make([]big.Int, r.Degree()-n+1)
+	inv := new(big.Int).ModInverse(&g.coeffs[n], f.p)
+	t := big.NewInt(0)
+	for r.Degree() >= n {
+		d := r.Degree()
+		c := &q.coeffs[d-n]
+		c.Mul(&r.coeffs[d], inv)
+		c.Mod(c, f.p)
+		for i := 0; i <= n; i++ {
+			t.Mul(c, &g.coeffs[i])
+			r.coeffs[d-n+i].Sub(&r.coeffs[d-n+i], t)
+			r.coeffs[d-n+i].Mod(&r.coeffs[d-n+i], f.p)
+		}
+		r.trim()
+	}
+	return q.trim(), r
+}
+
+func (f *ModPolynomial) Mod(g *ModPolynomial) *ModPolynomial {
+	_, r := f.DivMod(g)
+	return r
+}
+
+// Return the monic greatest common divisor of f and g.
+func (f *ModPolynomial) GCD(g *ModPolynomial) *ModPolynomial {
+	a, b := f, g
+	for !b.IsZero() {
+		a, b = b, a.Mod(b)
+	}
+	return a.Monic()
+}
+
+// Return the monic gcd d together with s and t such that s*f + t*g = d.
+func (f *ModPolynomial) ExtendedGCD(g *ModPolynomial) (*ModPolynomial, *ModPolynomial, *ModPolynomial) {
+	one := newModPolynomial(f.p, []big.Int{*big.NewInt(1)})
+	zero := newModPolynomial(f.p, nil)
+	a, b := f, g
+	s0, s1 := one, zero
+	t0, t1 := zero, one
+	for !b.IsZero() {
+		q, r := a.DivMod(b)
+		a, b = b, r
+		s0, s1 = s1, s0.Sub(q.Mul(s1))
+		t0, t1 = t1, t0.Sub(q.Mul(t1))
+	}
+	if a.IsZero() {
+		return a, s0, t0
+	}
+	inv := new(big.Int).ModInverse(&a.coeffs[a.Degree()], f.p)
+	return a.MulScalar(inv), s0.MulScalar(inv), t0.MulScalar(inv)
+}
+
+// Compute f^e mod m by repeated squaring.
+func (f *ModPolynomial) PowMod(e *big.Int, m *ModPolynomial) *ModPolynomial {
+	r := newModPolynomial(f.p, []big.Int{*big.NewInt(1)}).Mod(m)
+	s := f.Mod(m)
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r = r.Mul(r).Mod(m)
+		if e.Bit(i) == 1 {
+			r = r.Mul(s).Mod(m)
+		}
+	}
+	return r
+}
+
+// Compute f^e by repeated squaring.
+func (f *ModPolynomial) Pow(e uint) *ModPolynomial {
+	r := newModPolynomial(f.p, []big.Int{*big.NewInt(1)})
+	s := f
+	for ; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			r = r.Mul(s)
+		}
+		if e > 1 {
+			s = s.Mul(s)
+		}
+	}
+	return r
+}
+
+// Evaluate f at x modulo p.
+func (f *ModPolynomial) Eval(x *big.Int) *big.Int {
+	y := f.Lift().Eval(x)
+	return y.Mod(y, f.p)
+}
+
+func (f *ModPolynomial) Derivative() *ModPolynomial {
+	return f.Lift().Derivative().ModP(f.p)
+}
+
+// The polynomial x modulo p.
+func modPolynomialX(p *big.Int) *ModPolynomial {
+	return newModPolynomial(p, []big.Int{*big.NewInt(0), *big.NewInt(1)})
+}
+
+// Distinct-degree factorization of a monic, square-free polynomial.
+// Entry d of the result is the product of all irreducible factors of
+// degree d, or nil if there are none.
+// von zur Gathen and Gerhard, Alg. 14.3.
+func (f *ModPolynomial) distinctDegreeFactor() []*ModPolynomial {
+	x := modPolynomialX(f.p)
+	parts := make([]*ModPolynomial, f.Degree()+1)
+	h := x.Mod(f)
+	for d := 1; 2*d <= f.Degree(); d++ {
+		h = h.PowMod(f.p, f)
+		g := h.Sub(x).GCD(f)
+		if !g.IsOne() {
+			parts[d] = g
+			f, _ = f.DivMod(g)
+			h = h.Mod(f)
+		}
+	}
+	if f.Degree() > 0 {
+		parts[f.Degree()] = f
+	}
+	return parts
+}
+
+// Split a monic polynomial that is the product of distinct irreducible
+// factors, all of degree d, using Cantor-Zassenhaus. For odd p this
+// splits with gcd(a^((p^d - 1)/2) - 1, f); for p = 2 it uses the trace
+// a + a^2 + ... + a^(2^(d-1)) instead.
+// von zur Gathen and Gerhard, Alg. 14.8 and Exercise 14.16.
+func (f *ModPolynomial) equalDegreeFactor(d int, rng *rand.Rand) []*ModPolynomial {
+	if f.Degree() <= d {
+		return []*ModPolynomial{f}
+	}
+	// (p^d - 1) / 2
+	e := new(big.Int).Exp(f.p, big.NewInt(int64(d)), nil)
+	e.Sub(e, intOne)
+	e.Rsh(e, 1)
+	two := f.p.Cmp(big.NewInt(2)) == 0
+	one := newModPolynomial(f.p, []big.Int{*big.NewInt(1)})
+	for {
+		a := randomModPolynomial(f.p, f.Degree(), rng)
+		if a.Degree() < 1 {
+			continue
+		}
+		g := a.GCD(f)
+		if g.IsOne() {
+			var b *ModPolynomial
+			if two {
+				b = a
+				t := a
+				for i := 1; i < d; i++ {
+					t = t.Mul(t).Mod(f)
+					b = b.Add(t)
+				}
+			} else {
+				b = a.PowMod(e, f).Sub(one)
+			}
+			g = b.GCD(f)
+		}
+		if g.Degree() > 0 && g.Degree() < f.Degree() {
+			h, _ := f.DivMod(g)
+			return append(g.equalDegreeFactor(d, rng), h.equalDegreeFactor(d, rng)...)
+		}
+	}
+}
+
+// A random polynomial of degree less than n.
+func randomModPolynomial(p *big.Int, n int, rng *rand.Rand) *ModPolynomial {
+	coeffs := make([]big.Int, n)
+	for i := range coeffs {
+		coeffs[i].Rand(rng, p)
+	}
+	return newModPolynomial(p, coeffs)
+}
+
+// Factor a monic, square-free polynomial into monic irreducibles.
+func (f *ModPolynomial) factorSquareFree(rng *rand.Rand) []*ModPolynomial {
+	factors := []*ModPolynomial{}
+	for d, part := range f.distinctDegreeFactor() {
+		if part != nil {
+			factors = append(factors, part.equalDegreeFactor(d, rng)...)
+		}
+	}
+	return factors
+}
+
+// Distinct-degree factorization of a monic, square-free polynomial.
+// Entry d of the result is the product of all irreducible factors of
+// degree d, or nil if there are none.
+func (f *ModPolynomial) DistinctDegreeFactor() []*ModPolynomial {
+	return f.Monic().distinctDegreeFactor()
+}
+
+// Split a monic, square-free polynomial whose irreducible factors all
+// have degree d into those factors.
+func (f *ModPolynomial) EqualDegreeFactor(d int) []*ModPolynomial {
+	factors := f.Monic().equalDegreeFactor(d, rand.New(rand.NewSource(1)))
+	sort.Sort(modPolynomials(factors))
+	return factors
+}
+
+// Factor a square-free polynomial into monic irreducibles using
+// Berlekamp's algorithm. For small p the kernel vectors v of the
+// Berlekamp matrix are split with gcd(v - s, f) for every s; for large
+// p a random element of the kernel is split like in Cantor-Zassenhaus.
+// Cohen, Alg. 3.4.10 and 3.4.11.
+func (f *ModPolynomial) Berlekamp() []*ModPolynomial {
+	f = f.Monic()
+	n := f.Degree()
+	if n < 1 {
+		return nil
+	}
+	// Row i of q is x^(i*p) mod f.
+	q := newMatrix(n, n)
+	xp := modPolynomialX(f.p).PowMod(f.p, f)
+	row := newModPolynomial(f.p, []big.Int{*big.NewInt(1)})
+	for i := 0; i < n; i++ {
+		for j := range row.coeffs {
+			q[i][j].Set(&row.coeffs[j])
+		}
+		q[i][i].Sub(&q[i][i], intOne)
+		row = row.Mul(xp).Mod(f)
+	}
+	kernel := kernelModP(transpose(q), f.p)
+	vs := make([]*ModPolynomial, len(kernel))
+	for i := range kernel {
+		vs[i] = newModPolynomial(f.p, kernel[i])
+	}
+	r := len(kernel)
+	factors := []*ModPolynomial{f}
+	if f.p.BitLen() <= 16 {
+		s := big.NewInt(0)
+		for _, v := range vs {
+			if len(factors) == r {
+				break
+			}
+			if v.Degree() < 1 {
+				continue
+			}
+			next := []*ModPolynomial{}
+			for _, g := range factors {
+				for s.SetInt64(0); s.Cmp(f.p) < 0 && g.Degree() > 1; s.Add(s, intOne) {
+					h := v.Sub(newModPolynomial(f.p, []big.Int{*s})).GCD(g)
+					if h.Degree() > 0 && h.Degree() < g.Degree() {
+						next = append(next, h)
+						g, _ = g.DivMod(h)
+					}
+				}
+				next = append(next, g)
+			}
+			factors = next
+		}
+	} else {
+		rng := rand.New(rand.NewSource(1))
+		e := new(big.Int).Rsh(f.p, 1)
+		one := newModPolynomial(f.p, []big.Int{*big.NewInt(1)})
+		for len(factors) < r {
+			a := newModPolynomial(f.p, nil)
+			c := big.NewInt(0)
+			for _, v := range vs {
+				c.Rand(rng, f.p)
+				a = a.Add(v.MulScalar(c))
+			}
+			next := []*ModPolynomial{}
+			for _, g := range factors {
+				h := a.PowMod(e, g).Sub(one).GCD(g)
+				if h.Degree() > 0 && h.Degree() < g.Degree() {
+					k, _ := g.DivMod(h)
+					next = append(next, h, k)
+				} else {
+					next = append(next, g)
+				}
+			}
+			factors = next
+		}
+	}
+	sort.Sort(modPolynomials(factors))
+	return factors
+}
+
+// Compute the square-free decomposition of f: monic polynomials a_i,
+// each square-free, with f = lc(f) * prod a_i^i. Entry i of the result
+// is a_i, or nil (entry 0 is unused).
+// Cohen, Alg. 3.4.2.
+func (f *ModPolynomial) SquareFreeDecomposition() []*ModPolynomial {
+	parts := []*ModPolynomial{nil}
+	f = f.Monic()
+	if f.Degree() < 1 {
+		return parts
+	}
+	set := func(i int, a *ModPolynomial) {
+		for len(parts) <= i {
+			parts = append(parts, nil)
+		}
+		if parts[i] == nil {
+			parts[i] = a
+		} else {
+			parts[i] = parts[i].Mul(a)
+		}
+	}
+	g := f.GCD(f.Derivative())
+	w, _ := f.DivMod(g)
+	for i := 1; w.Degree() > 0; i++ {
+		y := w.GCD(g)
+		z, _ := w.DivMod(y)
+		if z.Degree() > 0 {
+			set(i, z)
+		}
+		w = y
+		g, _ = g.DivMod(y)
+	}
+	if g.Degree() > 0 {
+		// What is left is a p-th power.
+		p := int(f.p.Int64())
+		for i, a := range g.pthRoot().SquareFreeDecomposition() {
+			if a != nil {
+				set(i*p, a)
+			}
+		}
+	}
+	return parts
+}
+
+// Compute h with h^p = f, for f whose derivative is zero. Since a^p = a
+// in Z/pZ, h is obtained by keeping the coefficients of x^(kp).
+func (f *ModPolynomial) pthRoot() *ModPolynomial {
+	p := int(f.p.Int64())
+	coeffs := make([]big.Int, f.Degree()/p+1)
+	for i := range coeffs {
+		coeffs[i].Set(&f.coeffs[i*p])
+	}
+	return newModPolynomial(f.p, coeffs)
+}
+
+// Factor f into monic irreducibles, returning the leading coefficient
+// and the factors with their multiplicities.
+func (f *ModPolynomial) Factor() (*big.Int, []ModPolynomialFactor) {
+	lc := f.LeadingCoeff()
+	if f.Degree() < 1 {
+		return lc, nil
+	}
+	rng := rand.New(rand.NewSource(1))
+	factors := []ModPolynomialFactor{}
+	for e, part := range f.SquareFreeDecomposition() {
+		if part == nil {
+			continue
+		}
+		local := part.factorSquareFree(rng)
+		sort.Sort(modPolynomials(local))
+		for _, g := range local {
+			factors = append(factors, ModPolynomialFactor{g, e})
+		}
+	}
+	sort.Stable(modPolynomialFactors(factors))
+	return lc, factors
+}
+
+// Tell if f is irreducible, using Rabin's test: a polynomial of degree
+// n is irreducible if and only if x^(p^n) = x mod f and
+// gcd(x^(p^(n/q)) - x, f) = 1 for every prime q dividing n.
+func (f *ModPolynomial) IsIrreducible() bool {
+	n := f.Degree()
+	if n < 1 {
+		return false
+	}
+	f = f.Monic()
+	x := modPolynomialX(f.p).Mod(f)
+	powers := make([]*ModPolynomial, n+1)
+	powers[0] = x
+	for i := 1; i <= n; i++ {
+		powers[i] = powers[i-1].PowMod(f.p, f)
+	}
+	if !powers[n].Equal(x) {
+		return false
+	}
+	for _, q := range Factorization64(int64(n)) {
+		if !powers[n/int(q.prime)].Sub(x).GCD(f).IsOne() {
+			return false
+		}
+	}
+	return true
+}
+
+type modPolynomials []*ModPolynomial
+
+func (f modPolynomials) Len() int      { return len(f) }
+func (f modPolynomials) Swap(i, j int) { f[i], f[j] = f[j], f[i] }
+func (f modPolynomials) Less(i, j int) bool {
+	return f[i].less(f[j])
+}
+
+type modPolynomialFactors []ModPolynomialFactor
+
+func (f modPolynomialFactors) Len() int      { return len(f) }
+func (f modPolynomialFactors) Swap(i, j int) { f[i], f[j] = f[j], f[i] }
+func (f modPolynomialFactors) Less(i, j int) bool {
+	return f[i].Factor.less(f[j].Factor)
+}
+
+// Order by degree, then by coefficients from the top down.
+func (f *ModPolynomial) less(g *ModPolynomial) bool {
+	if f.Degree() != g.Degree() {
+		return f.Degree() < g.Degree()
+	}
+	for k := f.Degree(); k >= 0; k-- {
+		if c := f.coeffs[k].Cmp(&g.coeffs[k]); c != 0 {
+			return c < 0
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestModPolynomialArithmetic(t *testing.T) {
+	f := NewModPolynomial64(7, 3, 0, 1)
+	g := NewModPolynomial64(7, 6, 1)
+	if s := f.Add(g).String(); s != "x^2 + 1*x + 2 (mod 7)" {
+		t.Errorf("f + g = %s\n", s)
+	}
+	if s := f.Mul(g).String(); s != "x^3 + 6*x^2 + 3*x + 4 (mod 7)" {
+		t.Errorf("f * g = %s\n", s)
+	}
+	q, r := f.DivMod(g)
+	if !q.Mul(g).Add(r).Equal(f) || r.Degree() >= g.Degree() {
+		t.Errorf("DivMod(%s, %s) = %s, %s\n", f, g, q, r)
+	}
+	d, s, u := f.ExtendedGCD(g)
+	if !d.IsOne() || !s.Mul(f).Add(u.Mul(g)).Equal(d) {
+		t.Errorf("ExtendedGCD(%s, %s) = %s, %s, %s\n", f, g, d, s, u)
+	}
+	if v := f.Eval(big.NewInt(1)); v.Int64() != 4 {
+		t.Errorf("f(1) = %s\n", v)
+	}
+	if !NewIntPolynomial64(-4, 8, 15).ModP(big.NewInt(7)).Equal(NewModPolynomial64(7, 3, 1, 1)) {
+		t.Errorf("reduction mod 7 failed\n")
+	}
+}
+
+func TestModPolynomialFactor(t *testing.T) {
+	testCases := []struct {
+		p       int64
+		poly    string
+		factors []string
+		exps    []int
+	}{
+		{5, "x^4 + 1", []string{"x^2 + 2", "x^2 + 3"}, []int{1, 1}},
+		{17, "x^4 + 1", []string{"x + 2", "x + 8", "x + 9", "x + 15"}, []int{1, 1, 1, 1}},
+		{3, "x^3 + 1", []string{"x + 1"}, []int{3}},
+		{2, "x^7 - 1", []string{"x + 1", "x^3 + 1*x + 1", "x^3 + 1*x^2 + 1"}, []int{1, 1, 1}},
+		{2, "x^6 + x^4 + x^2 + 1", []string{"x + 1"}, []int{6}},
+		{3, "x^5 + x^3 + x^2 + 1", []string{"x + 1", "x^2 + 1"}, []int{3, 1}},
+	}
+	for _, testCase := range testCases {
+		f := ParseIntPoly(testCase.poly).ModP(big.NewInt(testCase.p))
+		_, factors := f.Factor()
+		ok := len(factors) == len(testCase.factors)
+		for i := 0; ok && i < len(factors); i++ {
+			ok = factors[i].Factor.Lift().String() == testCase.factors[i] && factors[i].Exponent == testCase.exps[i]
+		}
+		if !ok {
+			t.Errorf("factored %s as %v\n", f, factors)
+		}
+	}
+}
+
+func TestModPolynomialFactorMethods(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	for _, p := range []*big.Int{big.NewInt(2), big.NewInt(3), big.NewInt(101), big.NewInt(65537), new(big.Int).Sub(new(big.Int).Lsh(intOne, 89), intOne)} {
+		for i := 0; i < 5; i++ {
+			f := randomModPolynomial(p, 9, r).Mul(randomModPolynomial(p, 7, r)).Monic()
+			if f.Degree() < 1 || f.GCD(f.Derivative()).Degree() > 0 {
+				continue
+			}
+			cz := f.factorSquareFree(r)
+			b := f.Berlekamp()
+			if len(cz) != len(b) {
+				t.Errorf("Berlekamp and Cantor-Zassenhaus disagree on %s: %v and %v\n", f, b, cz)
+				continue
+			}
+			prod := newModPolynomial(p, []big.Int{*big.NewInt(1)})
+			for _, g := range b {
+				if !g.IsIrreducible() {
+					t.Errorf("Berlekamp gave reducible factor %s of %s\n", g, f)
+				}
+				prod = prod.Mul(g)
+			}
+			if !prod.Equal(f) {
+				t.Errorf("Berlekamp factors of %s multiply to %s\n", f, prod)
+			}
+		}
+	}
+}
+
+func TestModPolynomialIsIrreducible(t *testing.T) {
+	testCases := []struct {
+		p           int64
+		poly        string
+		irreducible bool
+	}{
+		{3, "x^2 + 1", true},
+		{5, "x^2 + 1", false},
+		{2, "x^4 + x + 1", true},
+		{2, "x^4 + x^2 + 1", false},
+		{7, "x^3 - 2", true},
+		{31, "x^3 - 2", false},
+		{2, "x^10 + x^3 + 1", true},
+		{2, "x^10 + x^3 + x + 1", false},
+	}
+	for _, testCase := range testCases {
+		f := ParseIntPoly(testCase.poly).ModP(big.NewInt(testCase.p))
+		if f.IsIrreducible() != testCase.irreducible {
+			t.Errorf("IsIrreducible(%s) should be %v\n", f, testCase.irreducible)
+		}
+	}
+}
+
+func TestDistinctDegreeFactor(t *testing.T) {
+	f := ParseIntPoly("x^6 - 1").ModP(big.NewInt(5))
+	parts := f.DistinctDegreeFactor()
+	if parts[1].Degree() != 2 || parts[2].Degree() != 4 {
+		t.Errorf("distinct-degree factorization of %s gave %v\n", f, parts)
+	}
+	if factors := parts[2].EqualDegreeFactor(2); len(factors) != 2 {
+		t.Errorf("equal-degree factorization of %s gave %v\n", parts[2], factors)
+	}
+}
@@ -0,0 +1,299 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// The largest exponent and the largest degree of any intermediate
+// result the parser accepts, to keep "x^999999999" or "(x+1)^1048576"
+// from allocating without bound.
+const (
+	maxParseExponent = 1 << 20
+	maxParseDegree   = 1 << 12
+)
+
+// A ParseError reports where and why a polynomial failed to parse.
+// Offset is the byte offset into the input.
+type ParseError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("mathx: parse error at offset %d: %s", e.Offset, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenPlus
+	tokenMinus
+	tokenTimes
+	tokenPower
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of input"
+	}
+	return strconv.Quote(t.text)
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func tokenize(s string) ([]token, error) {
+	tokens := []token{}
+	for i := 0; i < len(s); {
+		c := s[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case isDigit(c):
+			for i < len(s) && isDigit(s[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokenNumber, s[start:i], start})
+			continue
+		case isLetter(c):
+			for i < len(s) && (isLetter(s[i]) || isDigit(s[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokenIdent, s[start:i], start})
+			continue
+		}
+		var kind tokenKind
+		switch c {
+		case '+':
+			kind = tokenPlus
+		case '-':
+			kind = tokenMinus
+		case '*':
+			kind = tokenTimes
+		case '^':
+			kind = tokenPower
+		case '(':
+			kind = tokenLParen
+		case ')':
+			kind = tokenRParen
+		default:
+			return nil, &ParseError{start, fmt.Sprintf("unexpected character %q", c)}
+		}
+		i++
+		tokens = append(tokens, token{kind, s[start:i], start})
+	}
+	return append(tokens, token{tokenEOF, "", len(s)}), nil
+}
+
+type polyParser struct {
+	tokens   []token
+	pos      int
+	variable string
+}
+
+func (p *polyParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *polyParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *polyParser) errorf(t token, format string, args ...interface{}) error {
+	return &ParseError{t.offset, fmt.Sprintf(format, args...)}
+}
+
+// Parse a polynomial in one variable with integer coefficients, such as
+// "3x^2 - 2(x + 1)^3 + 7". Like terms are combined; products, powers
+// of parenthesized expressions and implicit multiplication are allowed.
+// Any name may be used for the variable, but only one per polynomial.
+// Two numbers in a row, as in "3 4", are an error, and so is any power
+// or product of degree above 4096.
+//
+// Grammar:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ["*"] unary }
+//	unary   = ("+" | "-") unary | power
+//	power   = primary [ "^" number ]
+//	primary = number | variable | "(" expr ")"
+func ParseIntPolynomial(s string) (*IntPolynomial, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &polyParser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, p.errorf(p.peek(), "empty polynomial")
+	}
+	poly, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "unexpected %s", t)
+	}
+	return poly, nil
+}
+
+func (p *polyParser) parseExpr() (*IntPolynomial, error) {
+	poly, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokenPlus && t.kind != tokenMinus {
+			return poly, nil
+		}
+		p.next()
+		term, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		if t.kind == tokenPlus {
+			poly = poly.Add(term)
+		} else {
+			poly = poly.Sub(term)
+		}
+	}
+}
+
+func (p *polyParser) parseTerm() (*IntPolynomial, error) {
+	poly, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch t.kind {
+		case tokenTimes:
+			p.next()
+			t = p.peek()
+		case tokenNumber:
+			// "3 4" or "x^2 2" is more likely a typo than a product.
+			if p.tokens[p.pos-1].kind == tokenNumber {
+				return nil, p.errorf(t, "unexpected number %s after a number", t)
+			}
+		case tokenIdent, tokenLParen:
+			// implicit multiplication, as in 3x or 2(x + 1)
+		default:
+			return poly, nil
+		}
+		factor, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if poly.Degree()+factor.Degree() > maxParseDegree {
+			return nil, p.errorf(t, "degree of the product exceeds %d", maxParseDegree)
+		}
+		poly = poly.Mul(factor)
+	}
+}
+
+func (p *polyParser) parseUnary() (*IntPolynomial, error) {
+	switch p.peek().kind {
+	case tokenPlus:
+		p.next()
+		return p.parseUnary()
+	case tokenMinus:
+		p.next()
+		poly, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return poly.Neg(), nil
+	}
+	return p.parsePower()
+}
+
+func (p *polyParser) parsePower() (*IntPolynomial, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenPower {
+		return base, nil
+	}
+	p.next()
+	t := p.next()
+	if t.kind != tokenNumber {
+		return nil, p.errorf(t, "expected a non-negative integer exponent, found %s", t)
+	}
+	e, err := strconv.Atoi(t.text)
+	if err != nil || e > maxParseExponent {
+		return nil, p.errorf(t, "exponent %s is too large", t.text)
+	}
+	if d := base.Degree(); d > 0 && e > maxParseDegree/d {
+		return nil, p.errorf(t, "degree of the power exceeds %d", maxParseDegree)
+	}
+	if p.peek().kind == tokenPower {
+		return nil, p.errorf(p.peek(), "ambiguous repeated exponent; use parentheses")
+	}
+	return base.Pow(uint(e)), nil
+}
+
+func (p *polyParser) parsePrimary() (*IntPolynomial, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		c, _ := new(big.Int).SetString(t.text, 10)
+		return NewIntPolynomial([]*big.Int{c}), nil
+	case tokenIdent:
+		if p.variable == "" {
+			p.variable = t.text
+		} else if p.variable != t.text {
+			return nil, p.errorf(t, "second variable %q in a polynomial in %q", t.text, p.variable)
+		}
+		return NewIntPolynomial64(0, 1), nil
+	case tokenLParen:
+		poly, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if r := p.next(); r.kind != tokenRParen {
+			return nil, p.errorf(r, "expected \")\", found %s", r)
+		}
+		return poly, nil
+	}
+	return nil, p.errorf(t, "unexpected %s", t)
+}
@@ -0,0 +1,359 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func randomIntPolynomial(r *rand.Rand, degree int) *IntPolynomial {
+	coeffs := make([]*big.Int, degree+1)
+	for i := range coeffs {
+		coeffs[i] = big.NewInt(r.Int63n(2000001) - 1000000)
+	}
+	coeffs[degree] = big.NewInt(r.Int63n(1000) + 1)
+	return NewIntPolynomial(coeffs)
+}
+
+func TestPolynomialArithmetic(t *testing.T) {
+	testCases := []struct {
+		a, b, sum, diff, prod string
+	}{
+		{"x + 1", "x - 1", "2*x", "2", "x^2 - 1"},
+		{"x^2 + 1", "-1*x^2 + 3", "4", "2*x^2 - 2", "-1*x^4 + 2*x^2 + 3"},
+		{"x^3", "0", "x^3", "x^3", "0"},
+		{"2*x^2 - 3*x + 5", "x^2 + x - 1", "3*x^2 - 2*x + 4", "x^2 - 4*x + 6", "2*x^4 - 1*x^3 + 8*x - 5"},
+	}
+	for _, testCase := range testCases {
+		a := ParseIntPoly(testCase.a)
+		b := ParseIntPoly(testCase.b)
+		if s := a.Add(b).String(); s != testCase.sum {
+			t.Errorf("(%s) + (%s) = %s, expected %s\n", a, b, s, testCase.sum)
+		}
+		if s := a.Sub(b).String(); s != testCase.diff {
+			t.Errorf("(%s) - (%s) = %s, expected %s\n", a, b, s, testCase.diff)
+		}
+		if s := a.Mul(b).String(); s != testCase.prod {
+			t.Errorf("(%s) * (%s) = %s, expected %s\n", a, b, s, testCase.prod)
+		}
+	}
+}
+
+func TestPolynomialZero(t *testing.T) {
+	p := ParseIntPoly("x^2 + x")
+	z := p.Sub(p)
+	if !z.IsZero() || z.Degree() != -1 || z.String() != "0" {
+		t.Errorf("p - p gave %s of degree %d\n", z, z.Degree())
+	}
+	if !NewIntPolynomial64(0, 0, 0).Equal(z) {
+		t.Errorf("zero coefficients were not trimmed\n")
+	}
+	if d := NewIntPolynomial64(1, 2, 0, 0).Degree(); d != 1 {
+		t.Errorf("expected degree 1 after trimming, got %d\n", d)
+	}
+}
+
+func TestPolynomialPow(t *testing.T) {
+	p := ParseIntPoly("x + 1")
+	if s := p.Pow(4).String(); s != "x^4 + 4*x^3 + 6*x^2 + 4*x + 1" {
+		t.Errorf("(x + 1)^4 = %s\n", s)
+	}
+	if s := p.Pow(0).String(); s != "1" {
+		t.Errorf("(x + 1)^0 = %s\n", s)
+	}
+	if s := p.Neg().Mul64(3).String(); s != "-3*x - 3" {
+		t.Errorf("-3(x + 1) = %s\n", s)
+	}
+	if v := p.Pow(3).Eval(big.NewInt(2)); v.Int64() != 27 {
+		t.Errorf("(2 + 1)^3 = %s\n", v)
+	}
+}
+
+func TestKaratsuba(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, degree := range []int{31, 32, 50, 100, 257} {
+		a := randomIntPolynomial(r, degree)
+		b := randomIntPolynomial(r, degree+r.Intn(20))
+		fast := a.Mul(b)
+		slow := new(IntPolynomial)
+		slow.coeffs = mulSchoolbook(a.coeffs, b.coeffs)
+		if !fast.Equal(slow.trim()) {
+			t.Errorf("Karatsuba and schoolbook products differ for degree %d\n", degree)
+		}
+	}
+}
+
+func TestPolynomialDivision(t *testing.T) {
+	a := ParseIntPoly("x^4 - 3*x^2 + 2*x + 7")
+	b := ParseIntPoly("x^2 + x - 1")
+	q, r, err := a.DivMod(b)
+	if err != nil || !q.Mul(b).Add(r).Equal(a) || r.Degree() >= b.Degree() {
+		t.Errorf("DivMod(%s, %s) gave %s, %s, %v\n", a, b, q, r, err)
+	}
+
+	b = ParseIntPoly("2*x + 1")
+	if _, _, err := a.DivMod(b); err != ErrNotDivisible {
+		t.Errorf("expected ErrNotDivisible dividing %s by %s, got %v\n", a, b, err)
+	}
+	q, r, _ = a.PseudoDivMod(b)
+	lhs := a.Mul64(16)
+	if !q.Mul(b).Add(r).Equal(lhs) || r.Degree() >= b.Degree() {
+		t.Errorf("PseudoDivMod(%s, %s) gave %s, %s\n", a, b, q, r)
+	}
+
+	c := ParseIntPoly("3*x^2 - 2")
+	if q, err := c.Mul(b).ExactDiv(b); err != nil || !q.Equal(c) {
+		t.Errorf("ExactDiv gave %s, %v\n", q, err)
+	}
+	if _, err := c.Add(NewIntPolynomial64(1)).Mul(b).Add(NewIntPolynomial64(2)).ExactDiv(b); err != ErrNotDivisible {
+		t.Errorf("ExactDiv should have failed, got %v\n", err)
+	}
+	if _, err := c.ExactDiv(NewIntPolynomial64()); err != ErrDivisionByZero {
+		t.Errorf("expected ErrDivisionByZero, got %v\n", err)
+	}
+}
+
+func TestPolynomialContent(t *testing.T) {
+	p := ParseIntPoly("-6*x^3 + 9*x - 12")
+	if c := p.Content(); c.Int64() != 3 {
+		t.Errorf("content of %s is %s\n", p, c)
+	}
+	if pp := p.PrimitivePart().String(); pp != "-2*x^3 + 3*x - 4" {
+		t.Errorf("primitive part of %s is %s\n", p, pp)
+	}
+}
+
+func TestPolynomialGCD(t *testing.T) {
+	testCases := []struct {
+		a, b, gcd string
+	}{
+		{"x^2 - 1", "x^2 + 2*x + 1", "x + 1"},
+		{"x^8 + x^6 - 3*x^4 - 3*x^3 + 8*x^2 + 2*x - 5", "3*x^6 + 5*x^4 - 4*x^2 - 9*x + 21", "1"},
+		{"6*x^2 + 12*x + 6", "4*x + 4", "2*x + 2"},
+		{"0", "-3*x + 6", "3*x - 6"},
+		{"x^3 - 2", "x^2 + 1", "1"},
+	}
+	for _, testCase := range testCases {
+		a := ParseIntPoly(testCase.a)
+		b := ParseIntPoly(testCase.b)
+		if g := a.GCD(b).String(); g != testCase.gcd {
+			t.Errorf("gcd(%s, %s) = %s, expected %s\n", a, b, g, testCase.gcd)
+		}
+	}
+
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 10; i++ {
+		g := randomIntPolynomial(r, 1+r.Intn(4)).PrimitivePart()
+		a := g.Mul(randomIntPolynomial(r, r.Intn(5)))
+		b := g.Mul(randomIntPolynomial(r, r.Intn(5)))
+		if _, err := a.ExactDiv(a.GCD(b)); err != nil {
+			t.Errorf("gcd(%s, %s) does not divide the first argument\n", a, b)
+		}
+		if _, err := a.GCD(b).ExactDiv(g.normalizeSign()); err != nil {
+			t.Errorf("gcd(%s, %s) is not divisible by %s\n", a, b, g)
+		}
+	}
+}
+
+func TestResultant(t *testing.T) {
+	testCases := []struct {
+		a, b      string
+		resultant int64
+	}{
+		{"x^2 - 2", "x^2 - 3", 1},
+		{"x - 2", "x^2 + 1", 5},
+		{"x^2 + 1", "x - 2", 5},
+		{"2*x^2 - 3", "3*x^3 + x - 1", -355},
+		{"x^2 - 1", "x^3 - x^2 + x - 1", 0},
+		{"3", "x^2 + x + 1", 9},
+	}
+	for _, testCase := range testCases {
+		a := ParseIntPoly(testCase.a)
+		b := ParseIntPoly(testCase.b)
+		if r := a.Resultant(b); r.Int64() != testCase.resultant {
+			t.Errorf("Res(%s, %s) = %s, expected %d\n", a, b, r, testCase.resultant)
+		}
+	}
+}
+
+var discriminantTestCases = []struct {
+	polyString   string
+	discriminant string
+}{
+	{"x + 3", "1"},
+	{"x^2 + 5*x - 1001", "4029"},
+	{"3*x^2 - x + 7", "-83"},
+	{"x^3 - x - 1", "-23"},
+	{"x^3 + x + 1", "-31"},
+	{"x^3 - 2", "-108"},
+	{"2*x^3 + 3*x^2 - x + 5", "-3763"},
+	{"x^3 + 88*x^2 - x + 1", "-2719751"},
+	{"x^4 + 1", "256"},
+	{"x^4 - x - 1", "-283"},
+	{"x^4 - 2", "-2048"},
+	{"x^4 + x^3 + x^2 + x + 1", "125"},
+	{"x^5 - x - 1", "2869"},
+	{"x^6 + x^5 + x^4 + x^3 + x^2 + x + 1", "-16807"},
+}
+
+func TestDiscriminant(t *testing.T) {
+	for _, testCase := range discriminantTestCases {
+		p := ParseIntPoly(testCase.polyString)
+		if d := p.Discriminant().String(); d != testCase.discriminant {
+			t.Errorf("disc(%s) = %s, expected %s\n", p, d, testCase.discriminant)
+		}
+	}
+}
+
+func TestFactor(t *testing.T) {
+	testCases := []struct {
+		poly    string
+		content int64
+		factors []string
+		exps    []int
+	}{
+		{"x^2 - 1", 1, []string{"x - 1", "x + 1"}, []int{1, 1}},
+		{"-2*x^3 + 2*x", -2, []string{"x - 1", "x", "x + 1"}, []int{1, 1, 1}},
+		{"x^4 + 1", 1, []string{"x^4 + 1"}, []int{1}},
+		{"x^4 - 10*x^2 + 1", 1, []string{"x^4 - 10*x^2 + 1"}, []int{1}},
+		{"x^6 - 1", 1, []string{"x - 1", "x + 1", "x^2 - 1*x + 1", "x^2 + 1*x + 1"}, []int{1, 1, 1, 1}},
+		{"x^5 + 2*x^4 + x^3 - x^2 - 2*x - 1", 1, []string{"x - 1", "x + 1", "x^2 + 1*x + 1"}, []int{1, 2, 1}},
+		{"6*x^2 + 5*x + 1", 1, []string{"2*x + 1", "3*x + 1"}, []int{1, 1}},
+		{"x^8 - 1", 1, []string{"x - 1", "x + 1", "x^2 + 1", "x^4 + 1"}, []int{1, 1, 1, 1}},
+		{"4*x^4 + 1", 1, []string{"2*x^2 - 2*x + 1", "2*x^2 + 2*x + 1"}, []int{1, 1}},
+	}
+	for _, testCase := range testCases {
+		p := ParseIntPoly(testCase.poly)
+		c, factors := p.Factor()
+		if c.Int64() != testCase.content || len(factors) != len(testCase.factors) {
+			t.Errorf("factored %s as %s * %v\n", p, c, factors)
+			continue
+		}
+		for i, f := range factors {
+			if f.Factor.String() != testCase.factors[i] || f.Exponent != testCase.exps[i] {
+				t.Errorf("factored %s as %s * %v\n", p, c, factors)
+				break
+			}
+		}
+	}
+}
+
+func TestFactorRandomProducts(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 10; i++ {
+		a := randomIntPolynomial(r, 1+r.Intn(4))
+		b := randomIntPolynomial(r, 1+r.Intn(4))
+		c := randomIntPolynomial(r, 1+r.Intn(4))
+		p := a.Mul(b).Mul(c).Mul(c)
+		content, factors := p.Factor()
+		q := NewIntPolynomial([]*big.Int{content})
+		for _, f := range factors {
+			if !f.Factor.IsIrreducible() {
+				t.Errorf("factor %s of %s is reducible\n", f.Factor, p)
+			}
+			q = q.Mul(f.Factor.Pow(uint(f.Exponent)))
+		}
+		if !q.Equal(p) {
+			t.Errorf("factors of %s multiply to %s\n", p, q)
+		}
+	}
+}
+
+func TestIsIrreducible(t *testing.T) {
+	irreducible := []string{"x", "x^3 - x - 1", "x^4 + 1", "x^5 - x - 1", "x^6 + x^5 + x^4 + x^3 + x^2 + x + 1", "x^4 - 10*x^2 + 1"}
+	reducible := []string{"2*x + 2", "x^3 - 1", "x^4 + 4", "x^6 + 1", "x^4 - 2*x^2 + 1", "x^3 + 2*x^2 + 2*x + 1"}
+	for _, s := range irreducible {
+		if !ParseIntPoly(s).IsIrreducible() {
+			t.Errorf("%s should be irreducible\n", s)
+		}
+	}
+	for _, s := range reducible {
+		if ParseIntPoly(s).IsIrreducible() {
+			t.Errorf("%s should be reducible\n", s)
+		}
+	}
+}
+
+func TestParseIntPolynomial(t *testing.T) {
+	testCases := []struct {
+		input, output string
+	}{
+		{"x + x", "2*x"},
+		{"+x^2 - 3", "x^2 - 3"},
+		{"3x^2 + 2x + 1", "3*x^2 + 2*x + 1"},
+		{"(x + 1)^3", "x^3 + 3*x^2 + 3*x + 1"},
+		{"2(x - 1)(x + 1)", "2*x^2 - 2"},
+		{"t^3 - t*t^2 + 5", "5"},
+		{"-(y - 2)^2", "-1*x^2 + 4*x - 4"},
+		{"x^2 * -1", "-1*x^2"},
+		{"12345678901234567890123 x", "12345678901234567890123*x"},
+		{"0", "0"},
+		{"  x  ^ 2 ", "x^2"},
+		{"x^2 * 2", "2*x^2"},
+		{"(x + 1) 2", "2*x + 2"},
+	}
+	for _, testCase := range testCases {
+		p, err := ParseIntPolynomial(testCase.input)
+		if err != nil {
+			t.Errorf("failed to parse %q: %v\n", testCase.input, err)
+		} else if s := p.String(); s != testCase.output {
+			t.Errorf("parsed %q as %s, expected %s\n", testCase.input, s, testCase.output)
+		}
+	}
+}
+
+func TestParseIntPolynomialErrors(t *testing.T) {
+	testCases := []struct {
+		input  string
+		offset int
+	}{
+		{"x^^2y", 2},
+		{"x + y", 4},
+		{"x^2 + $", 6},
+		{"(x + 1", 6},
+		{"x + 1)", 5},
+		{"", 0},
+		{"x^-1", 2},
+		{"x^2^3", 3},
+		{"3 * ", 4},
+		{"1 000", 2},
+		{"3 4", 2},
+		{"x^2 2", 4},
+		{"x^5000", 2},
+		{"(x^1000000)^1000000", 3},
+		{"(x+1)^1048576", 6},
+		{"(x^4000)(x^4000)", 8},
+	}
+	for _, testCase := range testCases {
+		p, err := ParseIntPolynomial(testCase.input)
+		perr, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("parsing %q should fail, got %s\n", testCase.input, p)
+		} else if perr.Offset != testCase.offset {
+			t.Errorf("parsing %q failed at offset %d, expected %d: %v\n", testCase.input, perr.Offset, testCase.offset, err)
+		}
+	}
+	func() {
+		defer func() {
+			if r := recover(); r != "mathx: parse error at offset 2: expected a non-negative integer exponent, found \"^\"" {
+				t.Errorf("ParseIntPoly(\"x^^2y\") panicked with %v\n", r)
+			}
+		}()
+		ParseIntPoly("x^^2y")
+	}()
+}
@@ -0,0 +1,360 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+)
+
+// How many random elements to try when looking for the second
+// generator of a prime ideal.
+const uniformizerTrials = 1000
+
+var ErrNotPrime = errors.New("mathx: argument is not a prime")
+var ErrNoUniformizer = errors.New("mathx: no uniformizer found for a prime ideal")
+
+// A prime ideal P of the ring of integers of a number field, lying above
+// the rational prime p, so that pO = ... P^e ... and O/P has p^f
+// elements. P is generated by p and an element pi.
+type PrimeIdeal struct {
+	field *NumberField
+	p     *big.Int
+	e, f  int
+	pi    *NumberFieldElement
+	// HNF basis with respect to the integral basis; it contains pO.
+	basis [][]big.Int
+}
+
+func (P *PrimeIdeal) Field() *NumberField {
+	return P.field
+}
+
+func (P *PrimeIdeal) Prime() *big.Int {
+	return new(big.Int).Set(P.p)
+}
+
+func (P *PrimeIdeal) RamificationIndex() int {
+	return P.e
+}
+
+func (P *PrimeIdeal) ResidueDegree() int {
+	return P.f
+}
+
+// Return p and pi with P = pO + pi O.
+func (P *PrimeIdeal) Generators() (*big.Int, *NumberFieldElement) {
+	return new(big.Int).Set(P.p), P.pi
+}
+
+// The norm of P, p^f.
+func (P *PrimeIdeal) Norm() *big.Int {
+	return new(big.Int).Exp(P.p, big.NewInt(int64(P.f)), nil)
+}
+
+func (P *PrimeIdeal) String() string {
+	return "(" + P.p.String() + ", " + P.pi.String() + ")"
+}
+
+// Decompose pO into prime ideals. When p does not divide the index of
+// the equation order, this uses the Dedekind-Kummer theorem on the
+// factorization of the defining polynomial modulo p; otherwise the
+// algebra O/pO is split directly, as in Cohen, Alg. 6.2.9.
+func (k *NumberField) PrimeDecomposition(p *big.Int) ([]*PrimeIdeal, error) {
+	if p.Sign() <= 0 || !p.ProbablyPrime(20) {
+		return nil, ErrNotPrime
+	}
+	o, err := k.ringOfIntegers()
+	if err != nil {
+		return nil, err
+	}
+	n := o.degree()
+	ideals := []*PrimeIdeal{}
+	if new(big.Int).Mod(o.index(), p).Sign() != 0 {
+		_, factors := o.poly.ModP(p).Factor()
+		for _, fac := range factors {
+			// The element h(t), written in the integral basis. When p
+			// is inert, h(t) = 0 mod p and pO is prime.
+			v := make([]big.Int, n)
+			if fac.Factor.Degree() == n {
+				v[0].Set(p)
+			} else {
+				h := fac.Factor.Lift()
+				for i := range h.coeffs {
+					v[i].Set(&h.coeffs[i])
+				}
+			}
+			c := o.coordinates(v, intOne)
+			P := &PrimeIdeal{field: k, p: p, e: fac.Exponent, f: fac.Factor.Degree()}
+			P.basis = o.idealFromElement(c, p)
+			P.pi = k.elementFromOrder(o, c)
+			ideals = append(ideals, P)
+		}
+		return ideals, nil
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for _, basis := range o.splitRadical(o.radical(p), p) {
+		P := &PrimeIdeal{field: k, p: p, basis: basis}
+		P.f = quotientDimension(basis, p)
+		P.e = o.ramificationIndex(basis, p)
+		pi, err := o.uniformizer(basis, p, P.f, rng)
+		if err != nil {
+			return nil, err
+		}
+		P.pi = k.elementFromOrder(o, pi)
+		ideals = append(ideals, P)
+	}
+	return ideals, nil
+}
+
+// Tell if p ramifies in k.
+func (k *NumberField) IsRamified(p *big.Int) (bool, error) {
+	ideals, err := k.PrimeDecomposition(p)
+	if err != nil {
+		return false, err
+	}
+	for _, P := range ideals {
+		if P.e > 1 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// The dimension of O/I over F_p, for an ideal I containing pO, which is
+// the number of diagonal entries of its HNF equal to p.
+func quotientDimension(basis [][]big.Int, p *big.Int) int {
+	d := 0
+	for i := range basis {
+		if basis[i][i].Cmp(p) == 0 {
+			d++
+		}
+	}
+	return d
+}
+
+// Reduce v modulo the ideal I, given by an HNF basis, which contains pO.
+// The result is supported on the coordinates i with I[i][i] = p, with
+// entries in [0, p).
+func reduceModIdeal(basis [][]big.Int, v []big.Int, p *big.Int) []big.Int {
+	r := make([]big.Int, len(v))
+	for i := range v {
+		r[i].Set(&v[i])
+	}
+	t := new(big.Int)
+	for j := len(r) - 1; j >= 0; j-- {
+		r[j].Mod(&r[j], p)
+		if r[j].Sign() == 0 || basis[j][j].Cmp(intOne) != 0 {
+			continue
+		}
+		c := new(big.Int).Set(&r[j])
+		for i := 0; i <= j; i++ {
+			r[i].Sub(&r[i], t.Mul(c, &basis[j][i]))
+		}
+	}
+	return r
+}
+
+// The ideal pO + xO.
+func (o *order) idealFromElement(x []big.Int, p *big.Int) [][]big.Int {
+	n := o.degree()
+	rows := make([][]big.Int, n)
+	for i := range rows {
+		rows[i] = o.mul(x, unitVector(n, i))
+	}
+	return hnfLower(rows, n, p)
+}
+
+// The ideal I + J for ideals containing pO.
+func addIdeals(a, b [][]big.Int, p *big.Int) [][]big.Int {
+	rows := append(copyMatrix(a), copyMatrix(b)...)
+	return hnfLower(rows, len(a), p)
+}
+
+// The ideal IJ + pO.
+func (o *order) mulIdealsModP(a, b [][]big.Int, p *big.Int) [][]big.Int {
+	rows := [][]big.Int{}
+	for i := range a {
+		for j := range b {
+			rows = append(rows, o.mul(a[i], b[j]))
+		}
+	}
+	return hnfLower(rows, o.degree(), p)
+}
+
+// Split an ideal I containing pO, with O/I a product of finite fields,
+// into the maximal ideals containing it. The subalgebra
+// B = {x : x^p = x} of O/I is F_p^g, where g is the number of maximal
+// ideals; if g > 1, any non-scalar b in B takes distinct values c in
+// F_p on the factors, and I splits into the ideals I + (b - c)O.
+func (o *order) splitRadical(basis [][]big.Int, p *big.Int) [][][]big.Int {
+	n := o.degree()
+	free := []int{}
+	for i := 0; i < n; i++ {
+		if basis[i][i].Cmp(p) == 0 {
+			free = append(free, i)
+		}
+	}
+	m := newMatrix(len(free), len(free))
+	for j, fj := range free {
+		e := unitVector(n, fj)
+		y := o.powMod(e, p, p)
+		y[fj].Sub(&y[fj], intOne)
+		y = reduceModIdeal(basis, y, p)
+		for i, fi := range free {
+			m[i][j].Set(&y[fi])
+		}
+	}
+	kernel := kernelModP(m, p)
+	if len(kernel) <= 1 {
+		return [][][]big.Int{basis}
+	}
+
+	// Column 0 is always free, since 1 is not in I. Pick b non-scalar.
+	var b []big.Int
+	for _, v := range kernel {
+		for i := 1; i < len(free); i++ {
+			if v[i].Sign() != 0 {
+				b = make([]big.Int, n)
+				for j, fj := range free {
+					b[fj].Set(&v[j])
+				}
+				break
+			}
+		}
+		if b != nil {
+			break
+		}
+	}
+
+	ideals := [][][]big.Int{}
+	for _, c := range o.minPolyRootsModIdeal(basis, b, p, free) {
+		x := make([]big.Int, n)
+		for i := range x {
+			x[i].Set(&b[i])
+		}
+		x[0].Sub(&x[0], c)
+		next := addIdeals(basis, o.idealFromElement(x, p), p)
+		ideals = append(ideals, o.splitRadical(next, p)...)
+	}
+	return ideals
+}
+
+// Find the roots in F_p of the minimal polynomial of b in O/I, which
+// must split into distinct linear factors.
+func (o *order) minPolyRootsModIdeal(basis [][]big.Int, b []big.Int, p *big.Int, free []int) []*big.Int {
+	n := o.degree()
+	powers := [][]big.Int{reduceModIdeal(basis, unitVector(n, 0), p)}
+	for {
+		x := reduceModIdeal(basis, o.mul(powers[len(powers)-1], b), p)
+		powers = append(powers, x)
+		m := newMatrix(len(free), len(powers))
+		for j := range powers {
+			for i, fi := range free {
+				m[i][j].Set(&powers[j][fi])
+			}
+		}
+		kernel := kernelModP(m, p)
+		if len(kernel) == 0 {
+			continue
+		}
+		coeffs := make([]*big.Int, len(powers))
+		for i := range coeffs {
+			coeffs[i] = &kernel[0][i]
+		}
+		_, factors := NewModPolynomial(p, coeffs).Factor()
+		roots := []*big.Int{}
+		for _, fac := range factors {
+			r := new(big.Int).Neg(fac.Factor.Coeff(0))
+			roots = append(roots, r.Mod(r, p))
+		}
+		return roots
+	}
+}
+
+// Compute the ramification index e of the prime P, the largest k such
+// that P^k + pO has codimension f k.
+func (o *order) ramificationIndex(basis [][]big.Int, p *big.Int) int {
+	power := basis
+	d := quotientDimension(basis, p)
+	for e := 1; ; e++ {
+		next := o.mulIdealsModP(power, basis, p)
+		dn := quotientDimension(next, p)
+		if dn == d {
+			return e
+		}
+		power, d = next, dn
+	}
+}
+
+// Find pi in P with P = pO + pi O, that is, with v_p(N(pi)) = f, trying
+// the basis vectors first and then random elements. If v_P(pi) > 1 then
+// pi + p works instead when e = 1. Returns ErrNoUniformizer if
+// uniformizerTrials random elements all fail.
+// Cohen, Alg. 4.7.10.
+func (o *order) uniformizer(basis [][]big.Int, p *big.Int, f int, rng *rand.Rand) ([]big.Int, error) {
+	n := o.degree()
+	for t := 0; t < n+uniformizerTrials; t++ {
+		var x []big.Int
+		if t < n {
+			x = reduceVector(copyMatrix(basis)[t], p)
+		} else {
+			x = make([]big.Int, n)
+			for _, row := range basis {
+				c := new(big.Int).Rand(rng, p)
+				for j := range x {
+					x[j].Add(&x[j], new(big.Int).Mul(c, &row[j]))
+				}
+			}
+			x = reduceVector(x, p)
+		}
+		if o.hasNormValuation(x, p, f) {
+			return x, nil
+		}
+		x[0].Add(&x[0], p)
+		if o.hasNormValuation(x, p, f) {
+			return x, nil
+		}
+	}
+	return nil, ErrNoUniformizer
+}
+
+// Tell if the norm of x is exactly divisible by p^f.
+func (o *order) hasNormValuation(x []big.Int, p *big.Int, f int) bool {
+	if isZeroVector(x) {
+		return false
+	}
+	d := determinant(o.multiplicationMatrix(x))
+	pf := new(big.Int).Exp(p, big.NewInt(int64(f)), nil)
+	r := new(big.Int)
+	d.QuoRem(d, pf, r)
+	return r.Sign() == 0 && r.Mod(d, p).Sign() != 0
+}
+
+// The matrix whose column j holds the coordinates of x w_j.
+func (o *order) multiplicationMatrix(x []big.Int) [][]big.Int {
+	n := o.degree()
+	m := newMatrix(n, n)
+	for j := 0; j < n; j++ {
+		y := o.mul(x, unitVector(n, j))
+		for i := 0; i < n; i++ {
+			m[i][j].Set(&y[i])
+		}
+	}
+	return m
+}
@@ -0,0 +1,624 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"errors"
+	"math/big"
+)
+
+var ErrNotQuadratic = errors.New("mathx: field is not quadratic")
+var ErrDiscriminantMismatch = errors.New("mathx: discriminant does not match the field")
+
+// A binary quadratic form a x^2 + b x y + c y^2 with integer
+// coefficients. Forms are immutable; operations return new forms.
+type QuadraticForm struct {
+	a, b, c *big.Int
+}
+
+func NewQuadraticForm(a, b, c *big.Int) *QuadraticForm {
+	return &QuadraticForm{new(big.Int).Set(a), new(big.Int).Set(b), new(big.Int).Set(c)}
+}
+
+func NewQuadraticForm64(a, b, c int64) *QuadraticForm {
+	return &QuadraticForm{big.NewInt(a), big.NewInt(b), big.NewInt(c)}
+}
+
+// The form (a, b, (b^2 - D) / 4a).
+func newFormAB(a, b, D *big.Int) *QuadraticForm {
+	c := new(big.Int).Mul(b, b)
+	c.Sub(c, D)
+	c.Quo(c, new(big.Int).Lsh(a, 2))
+	return &QuadraticForm{new(big.Int).Set(a), new(big.Int).Set(b), c}
+}
+
+// Return the principal form (1, b, c) of discriminant D, with b = 0 or
+// 1, or nil unless D is 0 or 1 modulo 4.
+func PrincipalForm(D *big.Int) *QuadraticForm {
+	if r := new(big.Int).Mod(D, big.NewInt(4)).Int64(); r > 1 {
+		return nil
+	}
+	return newFormAB(intOne, big.NewInt(int64(D.Bit(0))), D)
+}
+
+// Return a reduced form properly equivalent to (p, b, c) of
+// discriminant D for the prime p, or nil if there is none, that is, if
+// D is not a square modulo 4p. Also nil if p is not prime.
+func PrimeForm(D, p *big.Int) *QuadraticForm {
+	if !p.ProbablyPrime(20) {
+		return nil
+	}
+	var b *big.Int
+	if p.Cmp(big.NewInt(2)) == 0 {
+		switch new(big.Int).Mod(D, big.NewInt(8)).Int64() {
+		case 0:
+			b = big.NewInt(0)
+		case 1:
+			b = big.NewInt(1)
+		case 4:
+			b = big.NewInt(2)
+		default:
+			return nil
+		}
+	} else {
+		r := new(big.Int).Mod(D, p)
+		b = new(big.Int).ModSqrt(r, p)
+		if b == nil {
+			return nil
+		}
+		if b.Bit(0) != D.Bit(0) {
+			b.Sub(p, b)
+		}
+	}
+	return newFormAB(p, b, D).Reduce()
+}
+
+func (f *QuadraticForm) A() *big.Int {
+	return new(big.Int).Set(f.a)
+}
+
+func (f *QuadraticForm) B() *big.Int {
+	return new(big.Int).Set(f.b)
+}
+
+func (f *QuadraticForm) C() *big.Int {
+	return new(big.Int).Set(f.c)
+}
+
+// Compute b^2 - 4ac.
+func (f *QuadraticForm) Discriminant() *big.Int {
+	d := new(big.Int).Mul(f.b, f.b)
+	return d.Sub(d, new(big.Int).Lsh(new(big.Int).Mul(f.a, f.c), 2))
+}
+
+func (f *QuadraticForm) IsPositiveDefinite() bool {
+	return f.Discriminant().Sign() < 0 && f.a.Sign() > 0
+}
+
+func (f *QuadraticForm) IsIndefinite() bool {
+	return f.Discriminant().Sign() > 0
+}
+
+func (f *QuadraticForm) IsPrimitive() bool {
+	g := new(big.Int).GCD(nil, nil, new(big.Int).Abs(f.a), new(big.Int).Abs(f.b))
+	g.GCD(nil, nil, g, new(big.Int).Abs(f.c))
+	return g.Cmp(intOne) == 0
+}
+
+// Evaluate f at (x, y).
+func (f *QuadraticForm) Eval(x, y *big.Int) *big.Int {
+	r := new(big.Int).Mul(f.a, x)
+	r.Add(r, new(big.Int).Mul(f.b, y))
+	r.Mul(r, x)
+	return r.Add(r, new(big.Int).Mul(new(big.Int).Mul(f.c, y), y))
+}
+
+func (f *QuadraticForm) Equal(g *QuadraticForm) bool {
+	return f.a.Cmp(g.a) == 0 && f.b.Cmp(g.b) == 0 && f.c.Cmp(g.c) == 0
+}
+
+func (f *QuadraticForm) String() string {
+	return "(" + f.a.String() + ", " + f.b.String() + ", " + f.c.String() + ")"
+}
+
+func (f *QuadraticForm) key() string {
+	return f.a.String() + " " + f.b.String()
+}
+
+// Tell if f is reduced. A definite form is reduced when
+// |b| <= |a| <= |c|, with a b >= 0 if either inequality is an equality;
+// an indefinite form when |sqrt(D) - 2|a|| < b < sqrt(D).
+func (f *QuadraticForm) IsReduced() bool {
+	D := f.Discriminant()
+	if D.Sign() < 0 {
+		a, c := new(big.Int).Abs(f.a), new(big.Int).Abs(f.c)
+		ab := f.b.CmpAbs(a)
+		ac := a.Cmp(c)
+		if ab > 0 || ac > 0 {
+			return false
+		}
+		return f.b.Sign()*f.a.Sign() >= 0 || ab != 0 && ac != 0
+	}
+	s := Sqrt(D)
+	if f.b.Sign() <= 0 || f.b.Cmp(s) > 0 {
+		return false
+	}
+	// For D not a square, b > |sqrt(D) - 2|a|| iff b >= s + 1 - 2|a|
+	// when 2|a| < sqrt(D), and b >= 2|a| - s otherwise.
+	a2 := new(big.Int).Abs(f.a)
+	a2.Lsh(a2, 1)
+	if a2.Cmp(s) <= 0 {
+		return f.b.Cmp(new(big.Int).Sub(s, a2)) > 0
+	}
+	return f.b.Cmp(new(big.Int).Sub(a2, s)) >= 0
+}
+
+// Return the reduced form properly equivalent to a definite form, or
+// the first reduced form reached by the reduction operator Rho for an
+// indefinite form with non-square discriminant. It panics if the
+// discriminant is a square.
+// Cohen, Alg. 5.4.2 and 5.6.5.
+func (f *QuadraticForm) Reduce() *QuadraticForm {
+	D := f.Discriminant()
+	if IsSquare(D) {
+		panic("mathx: form of square discriminant")
+	}
+	if D.Sign() > 0 {
+		g := f
+		for !g.IsReduced() {
+			g = g.Rho()
+		}
+		return g
+	}
+	if f.a.Sign() < 0 {
+		g := &QuadraticForm{new(big.Int).Neg(f.a), new(big.Int).Neg(f.b), new(big.Int).Neg(f.c)}
+		g, _ = g.reduceTransform()
+		return &QuadraticForm{g.a.Neg(g.a), g.b.Neg(g.b), g.c.Neg(g.c)}
+	}
+	g, _ := f.reduceTransform()
+	return g
+}
+
+// Reduce a positive definite form, also returning the matrix M with
+// f(M (x, y)) equal to the reduced form.
+func (f *QuadraticForm) reduceTransform() (*QuadraticForm, [2][2]*big.Int) {
+	D := f.Discriminant()
+	m := [2][2]*big.Int{{big.NewInt(1), big.NewInt(0)}, {big.NewInt(0), big.NewInt(1)}}
+	g := NewQuadraticForm(f.a, f.b, f.c)
+	a2, q, t := new(big.Int), new(big.Int), new(big.Int)
+	for {
+		// Substitute x - q y for x, with b - 2aq in (-a, a].
+		a2.Lsh(g.a, 1)
+		b := new(big.Int).Mod(g.b, a2)
+		if b.Cmp(g.a) > 0 {
+			b.Sub(b, a2)
+		}
+		q.Sub(g.b, b)
+		q.Quo(q, a2)
+		for i := 0; i < 2; i++ {
+			m[i][1].Sub(m[i][1], t.Mul(q, m[i][0]))
+		}
+		g = newFormAB(g.a, b, D)
+		if g.a.Cmp(g.c) > 0 || g.a.Cmp(g.c) == 0 && g.b.Sign() < 0 {
+			// Substitute (-y, x) for (x, y).
+			for i := 0; i < 2; i++ {
+				m[i][0], m[i][1] = m[i][1], m[i][0].Neg(m[i][0])
+			}
+			g = &QuadraticForm{g.c, new(big.Int).Neg(g.b), g.a}
+			continue
+		}
+		return g, m
+	}
+}
+
+// Apply the reduction operator to an indefinite form:
+// rho(a, b, c) = (c, r, (r^2 - D) / 4c), where r = -b modulo 2c, with
+// -|c| < r <= |c| if |c| > sqrt(D) and sqrt(D) - 2|c| < r < sqrt(D)
+// otherwise. The result is properly equivalent to f, and rho permutes
+// the reduced forms of each class in a cycle. It panics unless f is
+// indefinite of non-square discriminant.
+// Cohen, Def. 5.6.4.
+func (f *QuadraticForm) Rho() *QuadraticForm {
+	D := f.Discriminant()
+	checkIndefinite(D)
+	s := Sqrt(D)
+	c := new(big.Int).Abs(f.c)
+	c2 := new(big.Int).Lsh(c, 1)
+	r := new(big.Int).Neg(f.b)
+	if c.Cmp(s) > 0 {
+		r.Mod(r, c2)
+		if r.Cmp(c) > 0 {
+			r.Sub(r, c2)
+		}
+	} else {
+		// The largest r <= s congruent to -b.
+		t := new(big.Int).Sub(s, r)
+		t.Mod(t, c2)
+		r.Sub(s, t)
+	}
+	return newFormAB(f.c, r, D)
+}
+
+// Return the cycle of reduced forms properly equivalent to the
+// indefinite form f, starting from f.Reduce(). It panics unless f is
+// indefinite of non-square discriminant.
+func (f *QuadraticForm) Cycle() []*QuadraticForm {
+	checkIndefinite(f.Discriminant())
+	g := f.Reduce()
+	cycle := []*QuadraticForm{g}
+	for h := g.Rho(); !h.Equal(g); h = h.Rho() {
+		cycle = append(cycle, h)
+	}
+	return cycle
+}
+
+func checkIndefinite(D *big.Int) {
+	if D.Sign() <= 0 {
+		panic("mathx: form is not indefinite")
+	}
+	if IsSquare(D) {
+		panic("mathx: form of square discriminant")
+	}
+}
+
+// Compute the reduced inverse class (a, -b, c).
+func (f *QuadraticForm) Inverse() *QuadraticForm {
+	return (&QuadraticForm{f.a, new(big.Int).Neg(f.b), f.c}).Reduce()
+}
+
+// Tell if f and g are properly equivalent: for definite forms when
+// their reductions agree, and for indefinite forms when the reduction of
+// g lies in the cycle of f.
+func (f *QuadraticForm) IsEquivalent(g *QuadraticForm) bool {
+	if f.Discriminant().Cmp(g.Discriminant()) != 0 {
+		return false
+	}
+	if f.Discriminant().Sign() < 0 {
+		return f.Reduce().Equal(g.Reduce())
+	}
+	h := g.Reduce()
+	for _, x := range f.Cycle() {
+		if x.Equal(h) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tell if f is equivalent to the principal form.
+func (f *QuadraticForm) IsPrincipal() bool {
+	return f.IsEquivalent(PrincipalForm(f.Discriminant()))
+}
+
+// Compose two primitive forms of the same discriminant and reduce the
+// result. Positive definite forms use NUCOMP; others Gauss composition.
+func (f *QuadraticForm) Compose(g *QuadraticForm) *QuadraticForm {
+	D := f.Discriminant()
+	if D.Cmp(g.Discriminant()) != 0 {
+		panic("mathx: forms of different discriminants")
+	}
+	if f.IsPositiveDefinite() && g.IsPositiveDefinite() {
+		if f.Equal(g) {
+			return f.nudupl()
+		}
+		return f.nucomp(g)
+	}
+	return f.compose(g).Reduce()
+}
+
+// Compute the reduced square of f, using NUDUPL for positive definite
+// forms.
+func (f *QuadraticForm) Square() *QuadraticForm {
+	if f.IsPositiveDefinite() {
+		return f.nudupl()
+	}
+	return f.compose(f).Reduce()
+}
+
+// Compute the reduced form f^e, for any integer e.
+func (f *QuadraticForm) Pow(e *big.Int) *QuadraticForm {
+	g := f
+	if e.Sign() < 0 {
+		g = f.Inverse()
+		e = new(big.Int).Neg(e)
+	}
+	r := PrincipalForm(f.Discriminant())
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r = r.Square()
+		if e.Bit(i) == 1 {
+			r = r.Compose(g)
+		}
+	}
+	return r
+}
+
+// Gauss composition of two forms of the same discriminant, without
+// reduction.
+// Cohen, Alg. 5.4.7.
+func (f *QuadraticForm) compose(g *QuadraticForm) *QuadraticForm {
+	f1, f2 := f, g
+	if f1.a.CmpAbs(f2.a) > 0 {
+		f1, f2 = f2, f1
+	}
+	s := new(big.Int).Add(f1.b, f2.b)
+	s.Rsh(s, 1)
+	n := new(big.Int).Sub(f2.b, s)
+
+	y1, d := new(big.Int), new(big.Int)
+	if new(big.Int).Mod(f2.a, f1.a).Sign() == 0 {
+		d.Abs(f1.a)
+	} else {
+		extendedGCD(f2.a, f1.a, y1, new(big.Int), d)
+	}
+	x2, y2, d1 := new(big.Int), new(big.Int), new(big.Int)
+	if new(big.Int).Mod(s, d).Sign() == 0 {
+		y2.SetInt64(-1)
+		d1.Set(d)
+	} else {
+		extendedGCD(s, d, x2, y2, d1)
+		y2.Neg(y2)
+	}
+	v1 := new(big.Int).Quo(f1.a, d1)
+	v2 := new(big.Int).Quo(f2.a, d1)
+	r := new(big.Int).Mul(y1, y2)
+	r.Mul(r, n)
+	r.Sub(r, new(big.Int).Mul(x2, f2.c))
+	r.Mod(r, v1)
+	b3 := new(big.Int).Mul(v2, r)
+	b3.Lsh(b3, 1).Add(b3, f2.b)
+	a3 := new(big.Int).Mul(v1, v2)
+	return newFormAB(a3, b3, f.Discriminant())
+}
+
+// Compute u, v and d = gcd(x, y) >= 0 with u x + v y = d, for any signs
+// of x and y.
+func extendedGCD(x, y, u, v, d *big.Int) {
+	d.GCD(u, v, new(big.Int).Abs(x), new(big.Int).Abs(y))
+	if x.Sign() < 0 {
+		u.Neg(u)
+	}
+	if y.Sign() < 0 {
+		v.Neg(v)
+	}
+}
+
+// The bound |D/4|^(1/4) at which NUCOMP and NUDUPL stop the partial
+// Euclidean algorithm.
+func nucompBound(D *big.Int) *big.Int {
+	d := new(big.Int).Abs(D)
+	return Sqrt(Sqrt(d.Rsh(d, 2)))
+}
+
+// Run the extended Euclidean algorithm on (d, v3) until |v3| <= L,
+// keeping v and v2 with v3 = v2 * (start v3) modulo the start d.
+// Returns the number of steps.
+func partialEuclid(L, d, v3, v, v2 *big.Int) int {
+	v.SetInt64(0)
+	v2.SetInt64(1)
+	q, t3 := new(big.Int), new(big.Int)
+	z := 0
+	for ; v3.CmpAbs(L) > 0; z++ {
+		q.DivMod(d, v3, t3)
+		t2 := new(big.Int).Mul(q, v2)
+		t2.Sub(v, t2)
+		v.Set(v2)
+		d.Set(v3)
+		v2.Set(t2)
+		v3.Set(t3)
+	}
+	return z
+}
+
+// Compose two distinct positive definite forms with Shanks' NUCOMP,
+// which keeps the intermediate numbers of size about sqrt(|D|).
+// Cohen, Alg. 5.4.9; Jacobson and van der Poorten, 2002.
+func (f *QuadraticForm) nucomp(g *QuadraticForm) *QuadraticForm {
+	x, y := f, g
+	if x.a.Cmp(y.a) < 0 {
+		x, y = y, x
+	}
+	D := f.Discriminant()
+	L := nucompBound(D)
+	s := new(big.Int).Add(x.b, y.b)
+	s.Rsh(s, 1)
+	n := new(big.Int).Sub(y.b, s)
+	a1 := new(big.Int).Set(x.a)
+	a2 := new(big.Int).Set(y.a)
+	u, v, d := new(big.Int), new(big.Int), new(big.Int)
+	extendedGCD(a2, a1, u, v, d)
+	a := new(big.Int)
+	d1 := new(big.Int)
+	if d.Cmp(intOne) == 0 || new(big.Int).Mod(s, d).Sign() == 0 {
+		a.Mul(u, n).Neg(a)
+		d1.Set(d)
+		a1.Quo(a1, d1)
+		a2.Quo(a2, d1)
+		s.Quo(s, d1)
+	} else {
+		u1 := new(big.Int)
+		extendedGCD(s, d, u1, new(big.Int), d1)
+		a1.Quo(a1, d1)
+		a2.Quo(a2, d1)
+		s.Quo(s, d1)
+		d.Quo(d, d1)
+		p1 := new(big.Int).Mod(x.c, d)
+		p2 := new(big.Int).Mod(y.c, d)
+		l := new(big.Int).Mul(u, p1)
+		l.Add(l, new(big.Int).Mul(v, p2))
+		l.Mul(l, u1).Neg(l).Mod(l, d)
+		a.Mul(l, new(big.Int).Quo(a1, d))
+		a.Sub(a, new(big.Int).Mul(u, new(big.Int).Quo(n, d)))
+	}
+	a.Mod(a, a1)
+	if t := new(big.Int).Sub(a, a1); a.CmpAbs(t) > 0 {
+		a = t
+	}
+	dd := new(big.Int).Set(a1)
+	v3 := new(big.Int).Set(a)
+	v2 := new(big.Int)
+	z := partialEuclid(L, dd, v3, v, v2)
+
+	var qa, b2, gg, b *big.Int
+	if z == 0 {
+		gg = new(big.Int).Mul(v3, s)
+		gg.Add(gg, y.c).Quo(gg, dd)
+		b = a2
+		b2 = new(big.Int).Set(y.b)
+		v2.Set(d1)
+		qa = new(big.Int).Mul(dd, b)
+	} else {
+		if z&1 == 1 {
+			v3.Neg(v3)
+			v2.Neg(v2)
+		}
+		b = new(big.Int).Mul(a2, dd)
+		b.Add(b, new(big.Int).Mul(n, v)).Quo(b, a1)
+		e := new(big.Int).Mul(s, dd)
+		e.Add(e, new(big.Int).Mul(y.c, v)).Quo(e, a1)
+		q3 := new(big.Int).Mul(e, v2)
+		q4 := new(big.Int).Sub(q3, s)
+		b2 = new(big.Int).Add(q3, q4)
+		gg = new(big.Int).Quo(q4, v)
+		if d1.Cmp(intOne) != 0 {
+			v2.Mul(v2, d1)
+			v.Mul(v, d1)
+			b2.Mul(b2, d1)
+		}
+		qa = new(big.Int).Mul(dd, b)
+		qa.Add(qa, new(big.Int).Mul(e, v))
+	}
+	q1 := new(big.Int).Mul(b, v3)
+	q2 := new(big.Int).Add(q1, n)
+	qb := new(big.Int).Set(b2)
+	if z != 0 {
+		qb.Add(qb, q1).Add(qb, q2)
+	} else {
+		qb.Add(qb, new(big.Int).Lsh(q1, 1))
+	}
+	qc := new(big.Int).Mul(v3, new(big.Int).Quo(q2, dd))
+	qc.Add(qc, new(big.Int).Mul(gg, v2))
+	return (&QuadraticForm{qa, qb, qc}).Reduce()
+}
+
+// Square a positive definite form with Shanks' NUDUPL.
+// Cohen, Alg. 5.4.8.
+func (f *QuadraticForm) nudupl() *QuadraticForm {
+	D := f.Discriminant()
+	L := nucompBound(D)
+	a := new(big.Int).Set(f.a)
+	b := new(big.Int).Set(f.b)
+	u, d1 := new(big.Int), new(big.Int)
+	extendedGCD(b, a, u, new(big.Int), d1)
+	a.Quo(a, d1)
+	b.Quo(b, d1)
+	c := new(big.Int).Mul(u, f.c)
+	c.Neg(c).Mod(c, a)
+	if t := new(big.Int).Sub(c, a); c.CmpAbs(t) > 0 {
+		c = t
+	}
+	d := new(big.Int).Set(a)
+	v3 := c
+	v, v2 := new(big.Int), new(big.Int)
+	z := partialEuclid(L, d, v3, v, v2)
+	a2 := new(big.Int).Mul(d, d)
+	c2 := new(big.Int).Mul(v3, v3)
+	var qa, b2, g *big.Int
+	if z == 0 {
+		g = new(big.Int).Mul(v3, b)
+		g.Add(g, f.c).Quo(g, d)
+		b2 = new(big.Int).Set(f.b)
+		v2.Set(d1)
+		qa = a2
+	} else {
+		if z&1 == 1 {
+			v.Neg(v)
+			d.Neg(d)
+		}
+		e := new(big.Int).Mul(f.c, v)
+		e.Add(e, new(big.Int).Mul(b, d)).Quo(e, a)
+		g = new(big.Int).Mul(e, v2)
+		g.Sub(g, b).Quo(g, v)
+		b2 = new(big.Int).Mul(e, v2)
+		b2.Add(b2, new(big.Int).Mul(v, g))
+		if d1.Cmp(intOne) != 0 {
+			b2.Mul(b2, d1)
+			v.Mul(v, d1)
+			v2.Mul(v2, d1)
+		}
+		qa = new(big.Int).Add(a2, new(big.Int).Mul(e, v))
+	}
+	t := new(big.Int).Add(d, v3)
+	t.Mul(t, t).Sub(t, a2).Sub(t, c2)
+	qb := new(big.Int).Add(b2, t)
+	qc := new(big.Int).Mul(g, v2)
+	qc.Add(qc, c2)
+	return (&QuadraticForm{qa, qb, qc}).Reduce()
+}
+
+// Map an ideal of a quadratic field to the primitive form
+// N(x a + y beta) / N(I) of discriminant D, where I = g (a Z + beta Z)
+// with beta = (-b + sqrt(D)) / 2 oriented by the chosen sqrt(D). This
+// induces the map from ideal classes to form classes, which for real
+// fields is the one of the narrow class group.
+func QuadraticFormFromIdeal(I *Ideal) (*QuadraticForm, error) {
+	k := I.field
+	if k.Degree() != 2 {
+		return nil, ErrNotQuadratic
+	}
+	D := k.Discriminant()
+	// The primitive part: the second basis vector is (t, h) in the
+	// integral basis; dividing by the content leaves (a, 0), (t', 1).
+	g := new(big.Int).GCD(nil, nil, &I.basis[0][0], &I.basis[1][1])
+	g.GCD(nil, nil, g, new(big.Int).Abs(&I.basis[1][0]))
+	a := new(big.Int).Quo(&I.basis[0][0], g)
+	beta := k.elementFromOrder(I.o, []big.Int{*new(big.Int).Quo(&I.basis[1][0], g), *new(big.Int).Quo(&I.basis[1][1], g)})
+	// Write 2 beta = u + v sqrt(D) with v = +-1, so that b = -u v.
+	sqrtD := k.sqrtDiscriminant(D)
+	sc := sqrtD.Coords()
+	bc := beta.Coords()
+	v := new(big.Rat).Quo(bc[1], sc[1])
+	v.Mul(v, big.NewRat(2, 1))
+	u := new(big.Rat).Mul(v, sc[0])
+	u.Sub(new(big.Rat).Mul(bc[0], big.NewRat(2, 1)), u)
+	b := new(big.Int).Set(u.Num())
+	if v.Sign() > 0 {
+		b.Neg(b)
+	}
+	return newFormAB(a, b, D), nil
+}
+
+// Map f = (a, b, c), primitive of the field discriminant, to the ideal
+// (|a| Z + (-b + sqrt(D)) / 2 Z) t of the quadratic field k, with t = 1
+// if a > 0 and t = sqrt(D) if a < 0, so that the map is inverse to
+// QuadraticFormFromIdeal on narrow classes.
+// Cohen, Thm. 5.2.9.
+func (f *QuadraticForm) Ideal(k *NumberField) (*Ideal, error) {
+	if k.Degree() != 2 {
+		return nil, ErrNotQuadratic
+	}
+	D := k.Discriminant()
+	if D == nil || D.Cmp(f.Discriminant()) != 0 {
+		return nil, ErrDiscriminantMismatch
+	}
+	sqrtD := k.sqrtDiscriminant(D)
+	x := sqrtD.Sub(k.NewElement([]*big.Rat{new(big.Rat).SetInt(f.b)}))
+	x = x.MulRat(big.NewRat(1, 2))
+	y := k.NewElement([]*big.Rat{new(big.Rat).SetInt(new(big.Int).Abs(f.a))})
+	if f.a.Sign() < 0 {
+		x, y = x.Mul(sqrtD), y.Mul(sqrtD)
+	}
+	return k.Ideal(y, x)
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// Random reduced primitive forms of discriminant D, from prime forms.
+func randomForms(D *big.Int, count int, rng *rand.Rand) []*QuadraticForm {
+	forms := []*QuadraticForm{}
+	for p := int64(2); len(forms) < count; p++ {
+		if !big.NewInt(p).ProbablyPrime(10) || rng.Intn(2) == 0 {
+			continue
+		}
+		if f := PrimeForm(D, big.NewInt(p)); f != nil {
+			forms = append(forms, f.Pow(big.NewInt(int64(rng.Intn(50)+1))))
+		}
+	}
+	return forms
+}
+
+func TestQuadraticFormReduce(t *testing.T) {
+	testCases := []struct {
+		form, reduced *QuadraticForm
+	}{
+		{NewQuadraticForm64(7, 11, 5), NewQuadraticForm64(1, 1, 5)},
+		{NewQuadraticForm64(5, 8, 5), NewQuadraticForm64(2, 2, 5)},
+		{NewQuadraticForm64(3, -3, 3), NewQuadraticForm64(3, 3, 3)},
+		{NewQuadraticForm64(-7, 11, -5), NewQuadraticForm64(-1, -1, -5)},
+		{NewQuadraticForm64(1, 0, -7), NewQuadraticForm64(1, 4, -3)},
+	}
+	for _, testCase := range testCases {
+		got := testCase.form.Reduce()
+		if !got.Equal(testCase.reduced) {
+			t.Errorf("%s reduced to %s, expected %s\n", testCase.form, got, testCase.reduced)
+		}
+		if !got.IsReduced() || !got.IsEquivalent(testCase.form) {
+			t.Errorf("%s reduced to %s, which is not reduced or equivalent\n", testCase.form, got)
+		}
+	}
+
+	// The cycle of (1, 0, -79), of discriminant 316 = 4 * 79, has the
+	// length 4 of the period of the continued fraction of sqrt(79).
+	cycle := NewQuadraticForm64(1, 0, -79).Cycle()
+	for _, f := range cycle {
+		if !f.IsReduced() || !f.IsPrincipal() {
+			t.Errorf("Form %s in the principal cycle is not reduced or principal\n", f)
+		}
+	}
+	if len(cycle) != 4 {
+		t.Errorf("Principal cycle of discriminant 316 has length %d, expected 4: %v\n", len(cycle), cycle)
+	}
+	if NewQuadraticForm64(3, 4, -5).IsPrincipal() {
+		t.Errorf("(3, 4, -5) of discriminant 76 should not be principal\n")
+	}
+}
+
+func TestPrimeForm(t *testing.T) {
+	D := big.NewInt(-23)
+	for _, p := range []int64{2, 3, 13} {
+		f := PrimeForm(D, big.NewInt(p))
+		if f == nil || f.Discriminant().Cmp(D) != 0 || !f.IsReduced() {
+			t.Errorf("PrimeForm(-23, %d) gave %v", p, f)
+		}
+	}
+	// 5 is not a square modulo 23, and the rest are not prime.
+	for _, p := range []int64{5, -3, 0, 1, 4, 9, 15, 1001} {
+		if f := PrimeForm(D, big.NewInt(p)); f != nil {
+			t.Errorf("PrimeForm(-23, %d) gave %s, expected nil", p, f)
+		}
+	}
+}
+
+func TestQuadraticFormReducePanics(t *testing.T) {
+	testCases := []struct {
+		name string
+		f    func()
+		msg  string
+	}{
+		{"(2, 5, 3).Reduce", func() { NewQuadraticForm64(2, 5, 3).Reduce() }, "mathx: form of square discriminant"},
+		{"(1, 2, 1).Reduce", func() { NewQuadraticForm64(1, 2, 1).Reduce() }, "mathx: form of square discriminant"},
+		{"(1, 1, 0).Cycle", func() { NewQuadraticForm64(1, 1, 0).Cycle() }, "mathx: form of square discriminant"},
+		{"(1, 1, 1).Cycle", func() { NewQuadraticForm64(1, 1, 1).Cycle() }, "mathx: form is not indefinite"},
+		{"(1, 1, 1).Rho", func() { NewQuadraticForm64(1, 1, 1).Rho() }, "mathx: form is not indefinite"},
+	}
+	for _, testCase := range testCases {
+		func() {
+			defer func() {
+				if r := recover(); r != testCase.msg {
+					t.Errorf("%s panicked with %v, expected %q\n", testCase.name, r, testCase.msg)
+				}
+			}()
+			testCase.f()
+		}()
+	}
+}
+
+func TestQuadraticFormComposition(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	discriminants := []*big.Int{big.NewInt(-23), big.NewInt(-3299), big.NewInt(-4 * 1000003)}
+	D, _ := new(big.Int).SetString("-1000000000000000000000000000000000000000000000000000000000000000000000007", 10)
+	discriminants = append(discriminants, D)
+	for _, D := range discriminants {
+		forms := randomForms(D, 8, rng)
+		for _, f := range forms {
+			if sq, g := f.Square(), f.compose(f).Reduce(); !sq.Equal(g) {
+				t.Errorf("NUDUPL of %s gave %s, expected %s\n", f, sq, g)
+			}
+			if !f.Compose(f.Inverse()).Equal(PrincipalForm(D)) {
+				t.Errorf("%s composed with its inverse is not principal\n", f)
+			}
+			for _, g := range forms {
+				h := f.Compose(g)
+				if e := f.compose(g).Reduce(); !h.Equal(e) {
+					t.Errorf("NUCOMP of %s and %s gave %s, expected %s\n", f, g, h, e)
+				}
+				if h.Discriminant().Cmp(D) != 0 || !h.IsReduced() || !h.IsPrimitive() {
+					t.Errorf("Composition of %s and %s gave %s\n", f, g, h)
+				}
+			}
+		}
+	}
+
+	// The class group of discriminant -3299 is C3 x C9.
+	D = big.NewInt(-3299)
+	for _, f := range randomForms(D, 10, rng) {
+		if !f.Pow(big.NewInt(9)).Equal(PrincipalForm(D)) {
+			t.Errorf("%s^9 is not principal\n", f)
+		}
+		if !f.Pow(big.NewInt(-4)).Equal(f.Pow(big.NewInt(5))) {
+			t.Errorf("%s^-4 is not %s^5\n", f, f)
+		}
+		if !f.Pow(big.NewInt(-3)).Equal(f.Pow(big.NewInt(6))) {
+			t.Errorf("%s^-3 is not %s^6\n", f, f)
+		}
+	}
+
+	// Indefinite forms of discriminant 316, whose form class group is
+	// the narrow class group C6 of Q(sqrt(79)).
+	D = big.NewInt(316)
+	f := PrimeForm(D, big.NewInt(3))
+	if f.Pow(big.NewInt(3)).IsPrincipal() || !f.Pow(big.NewInt(6)).IsPrincipal() {
+		t.Errorf("Expected %s of discriminant 316 to have order 6\n", f)
+	}
+	if !f.Pow(big.NewInt(3)).IsEquivalent(NewQuadraticForm64(-1, 0, 79)) {
+		t.Errorf("Expected %s^3 equivalent to (-1, 0, 79)\n", f)
+	}
+	if !f.Square().IsEquivalent(f.Pow(big.NewInt(-4))) {
+		t.Errorf("Expected %s^2 equivalent to %s^-4\n", f, f)
+	}
+}
+
+func TestQuadraticFormIdeals(t *testing.T) {
+	for _, poly := range []string{"x^2 + x + 825", "x^2 + 65", "2*x^2 + 2*x + 3", "x^2 - 79", "x^2 - 10"} {
+		k := MakeNumberField(ParseIntPoly(poly))
+		D := k.Discriminant()
+		for p := int64(2); p < 30; p++ {
+			if !big.NewInt(p).ProbablyPrime(10) {
+				continue
+			}
+			f := PrimeForm(D, big.NewInt(p))
+			if f == nil {
+				continue
+			}
+			I, err := f.Ideal(k)
+			if err != nil {
+				t.Fatalf("%s: ideal of %s: %v\n", poly, f, err)
+			}
+			if f.a.Sign() > 0 && I.Norm().Cmp(new(big.Rat).SetInt(f.a)) != 0 {
+				t.Errorf("%s: ideal %s of %s has norm %s\n", poly, I, f, I.Norm())
+			}
+			g, err := QuadraticFormFromIdeal(I)
+			if err != nil {
+				t.Fatalf("%s: form of %s: %v\n", poly, I, err)
+			}
+			if !g.IsEquivalent(f) {
+				t.Errorf("%s: form %s gave ideal %s and form %s\n", poly, f, I, g)
+			}
+			if D.Sign() > 0 {
+				continue
+			}
+			// The map to ideal classes is a homomorphism.
+			J, _ := f.Square().Ideal(k)
+			principal, _, err := I.Mul(I).Mul(J.Inverse()).IsPrincipal()
+			if err != nil || !principal {
+				t.Errorf("%s: I^2 / J is not principal for I = %s, J = %s\n", poly, I, J)
+			}
+		}
+	}
+}
@@ -16,58 +16,69 @@
 package mathx
 
 import (
+	"errors"
 	"math"
+	"math/big"
 )
 
-// cohen, 5.7.2,  p. 270
+var ErrNotRealQuadratic = errors.New("mathx: field is not real quadratic")
+
+// Compute the regulator log(e) of the real quadratic field defined by
+// poly, from its fundamental unit e.
 func (poly *IntPolynomial) regulatorRealQuad() float64 {
-	D := poly.Discriminant().Int64()
-	f := math.Sqrt(float64(D))
-	c := -poly.coeffs[0].Int64()
-	// try all of the small elements
-	for x := int64(1); x < 100; x++ {
-		for y := int64(1); y < 100; y++ {
-			z := x*x - c*y*y
-			if z == 4 || z == -4 {
-				u := x
-				v := y
-				return math.Log((float64(u) + float64(v)*math.Sqrt(float64(c))) / 2.0)
-			}
-		}
+	k := MakeNumberField(poly)
+	u, v, err := k.FundamentalUnit()
+	if err != nil {
+		return math.NaN()
+	}
+	D := k.Discriminant()
+	// e = (u + v sqrt(D)) / 2, with u and v possibly beyond float64.
+	prec := uint(u.BitLen() + 64)
+	e := new(big.Float).SetPrec(prec).SetInt(D)
+	e.Sqrt(e).Mul(e, new(big.Float).SetInt(v)).Add(e, new(big.Float).SetInt(u))
+	m := new(big.Float)
+	exp := e.MantExp(m)
+	f, _ := m.Float64()
+	return math.Log(f) + float64(exp-1)*math.Ln2
+}
+
+// Compute the fundamental unit e = (u + v sqrt(D)) / 2 > 1 of a real
+// quadratic field, where D is the field discriminant. Its norm
+// (u^2 - D v^2) / 4 is -1 or 1.
+func (k *NumberField) FundamentalUnit() (*big.Int, *big.Int, error) {
+	if k.Degree() != 2 || k.polynomial.Discriminant().Sign() < 0 {
+		return nil, nil, ErrNotRealQuadratic
 	}
-	d := int64(math.Floor(f))
-	var b int64
-	if d&1 == D&1 {
-		b = d
-	} else {
-		b = d - 1
+	o, err := k.ringOfIntegers()
+	if err != nil {
+		return nil, nil, err
 	}
-	var u, v int64
-	u1 := -b
-	u2 := int64(2)
-	v1 := int64(1)
-	v2 := int64(0)
-	p := b
-	q := int64(2)
-	for {
-		A := (p + d) / q
-		t := p
-		p = A*q - p
-		if t == p && v2 != 0 {
-			u = (u2*u2 + v2*v2*D) / q
-			v = (2 * u2 * v2) / q
-			break
-		}
-		u1, u2 = u2, A*u2+u1
-		v1, v2 = v2, A*v2+v1
-		t = q
-		q = (D - p*p) / q
+	u, v := fundamentalUnit(o.discriminant())
+	return u, v, nil
+}
 
-		if q == t && v2 != 0 {
-			u = (u1*u2 + D*v1*v2) / q
-			v = (u1*v2 + u2*v1) / q
-			break
-		}
+// Compute the fundamental unit (u + v sqrt(D)) / 2 of the order of
+// discriminant D > 0, D not a square. The continued fraction of the
+// reduced irrationality w = (b + sqrt(D)) / 2, with b = D mod 2 and
+// sqrt(D) - 2 < b < sqrt(D), is purely periodic; after the first period
+// of length k, w = (p_{k-1} w + p_{k-2}) / (q_{k-1} w + q_{k-2}), and
+// q_{k-1} w + q_{k-2} is the fundamental unit, of norm (-1)^k.
+// Cohen, Alg. 5.7.2.
+func fundamentalUnit(D *big.Int) (*big.Int, *big.Int) {
+	s := Sqrt(D)
+	b := new(big.Int).Set(s)
+	if b.Bit(0) != D.Bit(0) {
+		b.Sub(b, intOne)
+	}
+	// (b + sqrt(D)) / 2 is reduced, so its expansion is purely periodic.
+	cf, _ := QuadraticContinuedFraction(b, D, big.NewInt(2))
+	n := len(cf.period)
+	_, qs := cf.convergents(n)
+	q, qPrev := qs[n-1], big.NewInt(0)
+	if n > 1 {
+		qPrev = qs[n-2]
 	}
-	return math.Log((math.Abs(float64(u)) + math.Abs(float64(v))*f) / 2.0)
+	t := new(big.Int)
+	u := new(big.Int).Mul(b, q)
+	return u.Add(u, t.Lsh(qPrev, 1)), q
 }
@@ -0,0 +1,66 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+// Count the distinct real roots of p by Sturm's theorem: the sequence
+// p_0 = p, p_1 = p', p_{i+1} = -(p_{i-1} mod p_i) loses
+// V(-oo) - V(+oo) sign changes. Pseudo-remainders are scaled by a
+// positive factor and reduced to their primitive parts, which keeps the
+// signs of the sequence.
+// Cohen, Alg. 4.1.11.
+func (p *IntPolynomial) RealRootCount() int {
+	if p.Degree() < 1 {
+		return 0
+	}
+	seq := []*IntPolynomial{p.PrimitivePart(), p.Derivative().PrimitivePart()}
+	for {
+		a, b := seq[len(seq)-2], seq[len(seq)-1]
+		if b.Degree() < 1 {
+			break
+		}
+		_, r, _ := a.PseudoDivMod(b)
+		if r.IsZero() {
+			break
+		}
+		// r = lc(b)^e a mod b, so flip it when lc(b)^e < 0.
+		if b.LeadingCoeff().Sign() > 0 || (a.Degree()-b.Degree())&1 == 1 {
+			r = r.Neg()
+		}
+		seq = append(seq, r.PrimitivePart())
+	}
+	changes := func(atMinusInfinity bool) int {
+		n, last := 0, 0
+		for _, q := range seq {
+			s := q.LeadingCoeff().Sign()
+			if atMinusInfinity && q.Degree()&1 == 1 {
+				s = -s
+			}
+			if last != 0 && s != last {
+				n++
+			}
+			last = s
+		}
+		return n
+	}
+	return changes(true) - changes(false)
+}
+
+// Compute the signature (r1, r2) of k: r1 real embeddings and r2 pairs
+// of complex ones, with r1 + 2 r2 the degree.
+func (k *NumberField) Signature() (int, int) {
+	r1 := k.polynomial.RealRootCount()
+	return r1, (k.Degree() - r1) / 2
+}
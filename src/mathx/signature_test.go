@@ -0,0 +1,66 @@
+// Copyright (c) 2014 Christopher Swenson.
+// Copyright (c) 2012 Google, Inc. All Rights Reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+// http://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mathx
+
+import "testing"
+
+func TestSignature(t *testing.T) {
+	testCases := []struct {
+		polyString string
+		r1, r2     int
+	}{
+		{"x^2 - 2", 2, 0},
+		{"x^2 + 1", 0, 1},
+		{"x^3 - x - 1", 1, 1},
+		{"x^3 + x^2 - 2*x - 1", 3, 0},
+		{"x^4 - 10*x^2 + 1", 4, 0},
+		{"x^4 + 1", 0, 2},
+		{"x^4 - 2", 2, 1},
+		{"-x^5 + x + 1", 1, 2},
+	}
+	for _, c := range testCases {
+		k := MakeNumberField(ParseIntPoly(c.polyString))
+		if r1, r2 := k.Signature(); r1 != c.r1 || r2 != c.r2 {
+			t.Errorf("%s: expected (%d, %d), got (%d, %d)", c.polyString, c.r1, c.r2, r1, r2)
+		}
+	}
+}
+
+func TestIdealCounts(t *testing.T) {
+	testCases := []struct {
+		polyString string
+		counts     []int64
+	}{
+		// sum_{d | n} chi_-4(d).
+		{"x^2 + 1", []int64{0, 1, 1, 0, 1, 2, 0, 0, 1, 1, 2, 0, 0, 2}},
+		// 2 and 3 are totally ramified, 5 and 11 split into primes of
+		// degrees 1 and 2, and 7 and 13 are inert.
+		{"x^3 - 2", []int64{0, 1, 1, 1, 1, 1, 1, 0, 1, 1, 1, 1, 1, 0}},
+	}
+	for _, c := range testCases {
+		k := MakeNumberField(ParseIntPoly(c.polyString))
+		a, err := k.IdealCounts(len(c.counts) - 1)
+		if err != nil {
+			t.Fatalf("%s: %v", c.polyString, err)
+		}
+		for n := range a {
+			if a[n] != c.counts[n] {
+				t.Errorf("%s: expected counts %v, got %v", c.polyString, c.counts, a)
+				break
+			}
+		}
+	}
+}